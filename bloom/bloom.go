@@ -0,0 +1,234 @@
+// Package bloom provides a Bloom filter built on murmur3's 128 bit hash.
+//
+// Each element is hashed once with murmur3.Sum128, and the k bit positions
+// are derived from the two halves of that sum with Kirsch-Mitzenmacher double
+// hashing: position i is h1 + i*h2 modulo the filter size. Two filters must
+// therefore have the same size and number of hashes to be combined.
+package bloom
+
+import (
+	"encoding"
+	"errors"
+	"math"
+	"math/bits"
+
+	"github.com/twmb/murmur3"
+)
+
+// Make sure interfaces are correctly implemented.
+var (
+	_ encoding.BinaryMarshaler   = new(Filter)
+	_ encoding.BinaryUnmarshaler = new(Filter)
+)
+
+var (
+	errShape = errors.New("bloom: filters have different sizes or hash counts")
+	errState = errors.New("bloom: invalid filter encoding")
+)
+
+// magic prefixes a marshaled filter and identifies its layout version.
+const magic = "mm3b\x01"
+
+// Filter is a Bloom filter of m bits probed by k hashes. The zero value is not
+// usable; create filters with New or NewWithEstimates.
+//
+// A Filter is not safe for concurrent use.
+type Filter struct {
+	m    uint64
+	k    uint64
+	bits []uint64
+}
+
+// MaxK is the largest number of hashes a filter uses.
+const MaxK = 1024
+
+// New returns a filter of m bits that sets k bits per element. Both m and k
+// are raised to at least one, and k is lowered to at most MaxK.
+func New(m, k uint) *Filter {
+	if m < 1 {
+		m = 1
+	}
+	if k < 1 {
+		k = 1
+	}
+	if k > MaxK {
+		k = MaxK
+	}
+	return &Filter{
+		m:    uint64(m),
+		k:    uint64(k),
+		bits: make([]uint64, (m+63)/64),
+	}
+}
+
+// NewWithEstimates returns a filter sized for n elements at a false positive
+// rate of fpRate, using the optimal m = -n ln(p) / ln(2)^2 and
+// k = m/n ln(2).
+//
+// NewWithEstimates panics under the same conditions as EstimateParameters.
+func NewWithEstimates(n uint, fpRate float64) *Filter {
+	m, k := EstimateParameters(n, fpRate)
+	return New(m, k)
+}
+
+// EstimateParameters returns the number of bits and hashes NewWithEstimates
+// would use for n elements at a false positive rate of fpRate. An n of zero
+// is raised to one.
+//
+// EstimateParameters panics if fpRate is not strictly between 0 and 1, or if
+// the filter would need more bits than fit in an int.
+func EstimateParameters(n uint, fpRate float64) (m, k uint) {
+	if !(fpRate > 0 && fpRate < 1) {
+		panic("bloom: false positive rate must be strictly between 0 and 1")
+	}
+	if n < 1 {
+		n = 1
+	}
+	mf := math.Ceil(-float64(n) * math.Log(fpRate) / (math.Ln2 * math.Ln2))
+	if mf >= float64(^uint(0)>>1) {
+		panic("bloom: too many elements for the false positive rate")
+	}
+	m = uint(mf)
+	k = uint(math.Ceil(math.Ln2 * float64(m) / float64(n)))
+	if k > MaxK {
+		k = MaxK
+	}
+	return m, k
+}
+
+// Cap returns the number of bits in the filter.
+func (f *Filter) Cap() uint { return uint(f.m) }
+
+// K returns the number of bits set per element.
+func (f *Filter) K() uint { return uint(f.k) }
+
+func (f *Filter) add(h1, h2 uint64) {
+	for i := uint64(0); i < f.k; i++ {
+		loc := (h1 + i*h2) % f.m
+		f.bits[loc/64] |= 1 << (loc % 64)
+	}
+}
+
+func (f *Filter) test(h1, h2 uint64) bool {
+	for i := uint64(0); i < f.k; i++ {
+		loc := (h1 + i*h2) % f.m
+		if f.bits[loc/64]&(1<<(loc%64)) == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// Add adds data to the filter.
+func (f *Filter) Add(data []byte) {
+	f.add(murmur3.Sum128(data))
+}
+
+// AddString is the string version of Add.
+func (f *Filter) AddString(data string) {
+	f.add(murmur3.StringSum128(data))
+}
+
+// Test returns whether data may be in the filter. False positives are
+// possible; false negatives are not.
+func (f *Filter) Test(data []byte) bool {
+	return f.test(murmur3.Sum128(data))
+}
+
+// TestString is the string version of Test.
+func (f *Filter) TestString(data string) bool {
+	return f.test(murmur3.StringSum128(data))
+}
+
+// Clear removes every element from the filter.
+func (f *Filter) Clear() {
+	for i := range f.bits {
+		f.bits[i] = 0
+	}
+}
+
+// Union adds every element of other to f. The filters must have the same size
+// and number of hashes.
+func (f *Filter) Union(other *Filter) error {
+	if f.m != other.m || f.k != other.k {
+		return errShape
+	}
+	for i, w := range other.bits {
+		f.bits[i] |= w
+	}
+	return nil
+}
+
+// Intersect removes from f every bit not also set in other, leaving a filter
+// that tests positive for at least the elements in both. The filters must have
+// the same size and number of hashes.
+func (f *Filter) Intersect(other *Filter) error {
+	if f.m != other.m || f.k != other.k {
+		return errShape
+	}
+	for i, w := range other.bits {
+		f.bits[i] &= w
+	}
+	return nil
+}
+
+// ApproximateCardinality estimates the number of distinct elements added to
+// the filter from the number of set bits, per Swamidass and Baldi:
+// -m/k ln(1 - X/m). A full filter returns +Inf.
+func (f *Filter) ApproximateCardinality() float64 {
+	var set int
+	for _, w := range f.bits {
+		set += bits.OnesCount64(w)
+	}
+	m, k := float64(f.m), float64(f.k)
+	return -m / k * math.Log(1-float64(set)/m)
+}
+
+// MarshalBinary encodes the filter's size, hash count and bits.
+func (f *Filter) MarshalBinary() ([]byte, error) {
+	b := make([]byte, 0, len(magic)+16+8*len(f.bits))
+	b = append(b, magic...)
+	b = appendUint64(b, f.m)
+	b = appendUint64(b, f.k)
+	for _, w := range f.bits {
+		b = appendUint64(b, w)
+	}
+	return b, nil
+}
+
+// UnmarshalBinary replaces the filter with one encoded by MarshalBinary.
+func (f *Filter) UnmarshalBinary(b []byte) error {
+	if len(b) < len(magic)+16 || string(b[:len(magic)]) != magic {
+		return errState
+	}
+	b = b[len(magic):]
+	b, m := consumeUint64(b)
+	b, k := consumeUint64(b)
+	// The words must hold exactly the m bits; (m-1)/64 cannot overflow.
+	if m < 1 || k < 1 || k > MaxK || len(b)%8 != 0 || uint64(len(b)/8) != (m-1)/64+1 {
+		return errState
+	}
+	words := make([]uint64, len(b)/8)
+	for i := range words {
+		b, words[i] = consumeUint64(b)
+	}
+	// Bits above m in the last word are never set.
+	if m%64 != 0 && words[len(words)-1]>>(m%64) != 0 {
+		return errState
+	}
+	f.m, f.k, f.bits = m, k, words
+	return nil
+}
+
+func appendUint64(b []byte, x uint64) []byte {
+	return append(b,
+		byte(x>>56), byte(x>>48), byte(x>>40), byte(x>>32),
+		byte(x>>24), byte(x>>16), byte(x>>8), byte(x),
+	)
+}
+
+func consumeUint64(b []byte) ([]byte, uint64) {
+	x := uint64(b[7]) | uint64(b[6])<<8 | uint64(b[5])<<16 | uint64(b[4])<<24 |
+		uint64(b[3])<<32 | uint64(b[2])<<40 | uint64(b[1])<<48 | uint64(b[0])<<56
+	return b[8:], x
+}
@@ -0,0 +1,156 @@
+package bloom
+
+import (
+	"math"
+	"strconv"
+	"testing"
+)
+
+func TestEstimateParameters(t *testing.T) {
+	// The textbook sizing for a million elements at 1%: 9585059 bits and
+	// 7 hashes.
+	m, k := EstimateParameters(1000000, 0.01)
+	if m != 9585059 || k != 7 {
+		t.Errorf("EstimateParameters(1e6, 0.01) = %d, %d; want 9585059, 7", m, k)
+	}
+	f := NewWithEstimates(1000000, 0.01)
+	if f.Cap() != m || f.K() != k {
+		t.Errorf("NewWithEstimates shape = %d, %d; want %d, %d", f.Cap(), f.K(), m, k)
+	}
+
+	if _, k := EstimateParameters(1, 1e-320); k != MaxK {
+		t.Errorf("tiny fpRate: k = %d, want MaxK", k)
+	}
+	for _, fp := range []float64{0, 1, -0.5, 2, math.NaN()} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("EstimateParameters(1000, %v) did not panic", fp)
+				}
+			}()
+			EstimateParameters(1000, fp)
+		}()
+	}
+}
+
+func TestAddTest(t *testing.T) {
+	const n = 10000
+	f := NewWithEstimates(n, 0.01)
+	for i := 0; i < n; i++ {
+		if i%2 == 0 {
+			f.Add([]byte(strconv.Itoa(i)))
+		} else {
+			f.AddString(strconv.Itoa(i))
+		}
+	}
+	for i := 0; i < n; i++ {
+		s := strconv.Itoa(i)
+		if !f.Test([]byte(s)) || !f.TestString(s) {
+			t.Fatalf("false negative for %q", s)
+		}
+	}
+
+	var fp int
+	for i := n; i < 2*n; i++ {
+		if f.TestString(strconv.Itoa(i)) {
+			fp++
+		}
+	}
+	if rate := float64(fp) / n; rate > 0.02 {
+		t.Errorf("false positive rate %.4f, want about 0.01", rate)
+	}
+
+	if est := f.ApproximateCardinality(); math.Abs(est-n)/n > 0.05 {
+		t.Errorf("ApproximateCardinality = %.0f, want about %d", est, n)
+	}
+
+	f.Clear()
+	if f.TestString("0") || f.ApproximateCardinality() != 0 {
+		t.Error("filter not empty after Clear")
+	}
+}
+
+func TestUnionIntersect(t *testing.T) {
+	a, b := New(1<<16, 5), New(1<<16, 5)
+	a.AddString("a")
+	a.AddString("both")
+	b.AddString("b")
+	b.AddString("both")
+
+	u := New(1<<16, 5)
+	if err := u.Union(a); err != nil {
+		t.Fatal(err)
+	}
+	if err := u.Union(b); err != nil {
+		t.Fatal(err)
+	}
+	for _, s := range []string{"a", "b", "both"} {
+		if !u.TestString(s) {
+			t.Errorf("union missing %q", s)
+		}
+	}
+
+	if err := a.Intersect(b); err != nil {
+		t.Fatal(err)
+	}
+	if !a.TestString("both") {
+		t.Error("intersection missing \"both\"")
+	}
+	if a.TestString("a") || a.TestString("b") {
+		t.Error("intersection unexpectedly contains \"a\" or \"b\"")
+	}
+
+	if err := a.Union(New(1<<16, 4)); err == nil {
+		t.Error("Union of different hash counts succeeded")
+	}
+	if err := a.Intersect(New(1<<15, 5)); err == nil {
+		t.Error("Intersect of different sizes succeeded")
+	}
+}
+
+func TestMarshalBinary(t *testing.T) {
+	f := New(1000, 3)
+	for i := 0; i < 100; i++ {
+		f.AddString(strconv.Itoa(i))
+	}
+	b, err := f.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var g Filter
+	if err := g.UnmarshalBinary(b); err != nil {
+		t.Fatal(err)
+	}
+	if g.Cap() != f.Cap() || g.K() != f.K() {
+		t.Fatalf("unmarshaled shape %d, %d; want %d, %d", g.Cap(), g.K(), f.Cap(), f.K())
+	}
+	for i := 0; i < 100; i++ {
+		if !g.TestString(strconv.Itoa(i)) {
+			t.Fatalf("unmarshaled filter missing %d", i)
+		}
+	}
+
+	// A huge m whose word count overflows, an m that disagrees with the
+	// words present, an unbounded k, and a set bit above m.
+	header := func(m, k uint64) []byte {
+		return appendUint64(appendUint64([]byte(magic), m), k)
+	}
+	for _, bad := range [][]byte{
+		nil,
+		b[:len(b)-1],
+		append([]byte("xxxx"), b[4:]...),
+		header(math.MaxUint64, 3),
+		header(math.MaxUint64-62, 3),
+		append(header(65, 3), make([]byte, 8)...),
+		append(header(64, MaxK+1), make([]byte, 8)...),
+		appendUint64(header(63, 3), 1<<63),
+	} {
+		if err := g.UnmarshalBinary(bad); err == nil {
+			t.Errorf("UnmarshalBinary(%d bytes) succeeded", len(bad))
+		}
+	}
+	if err := g.UnmarshalBinary(appendUint64(header(63, 3), 1<<62)); err != nil {
+		t.Errorf("UnmarshalBinary with bit m-1 set: %v", err)
+	}
+}
@@ -0,0 +1,128 @@
+// Package cassandra computes the tokens that Apache Cassandra's
+// Murmur3Partitioner assigns to partition keys, so that clients can route
+// requests to the replicas owning a key.
+//
+// Cassandra's MurmurHash.hash3_x64_128 reads the final, partial block of a key
+// as signed Java bytes: any tail byte of 0x80 or more is sign extended before
+// being shifted into place. Keys whose tail bytes are all below 0x80 hash
+// exactly as murmur3.Sum128; most others do not, which is why this package
+// exists.
+package cassandra
+
+import (
+	"math"
+	"math/bits"
+
+	"github.com/twmb/murmur3"
+)
+
+const (
+	c1 = 0x87c37b91114253d5
+	c2 = 0x4cf5ad432745937f
+)
+
+// MinToken and MaxToken bound the tokens of the Murmur3Partitioner. MinToken
+// is the partitioner's minimum token: the only key assigned it is the empty
+// key, and a non-empty key hashing to it is moved to MaxToken.
+const (
+	MinToken = math.MinInt64
+	MaxToken = math.MaxInt64
+)
+
+// Token returns the Murmur3Partitioner token of a serialized partition key:
+// MinToken for an empty key, and otherwise the first half of Cassandra's
+// signed x64_128 hash with a seed of zero, with MinToken mapped to MaxToken.
+//
+// For partition keys with more than one column, serialize the key with
+// CompositeKey first.
+func Token(key []byte) int64 {
+	if len(key) == 0 {
+		return MinToken
+	}
+	h1, _ := Sum128(key)
+	if t := int64(h1); t != MinToken {
+		return t
+	}
+	return MaxToken
+}
+
+// CompositeToken is shorthand for Token(CompositeKey(components...)).
+func CompositeToken(components ...[]byte) int64 {
+	return Token(CompositeKey(components...))
+}
+
+// CompositeKey serializes the components of a multi column partition key as
+// Cassandra's CompositeType does: each component is prefixed with its two
+// byte big endian length and followed by a zero end-of-component byte.
+//
+// Each component must already be serialized as Cassandra expects for its
+// type (for example, a big endian int for an int column) and must be shorter
+// than 64KiB.
+func CompositeKey(components ...[]byte) []byte {
+	n := 0
+	for _, c := range components {
+		n += 2 + len(c) + 1
+	}
+	key := make([]byte, 0, n)
+	for _, c := range components {
+		key = append(key, byte(len(c)>>8), byte(len(c)))
+		key = append(key, c...)
+		key = append(key, 0)
+	}
+	return key
+}
+
+// Sum128 returns Cassandra's variant of the murmur3 x64_128 hash of key with
+// a seed of zero.
+func Sum128(key []byte) (h1, h2 uint64) {
+	clen := len(key)
+	for len(key) >= 16 {
+		k1 := uint64(key[0]) | uint64(key[1])<<8 | uint64(key[2])<<16 | uint64(key[3])<<24 | uint64(key[4])<<32 | uint64(key[5])<<40 | uint64(key[6])<<48 | uint64(key[7])<<56
+		k2 := uint64(key[8]) | uint64(key[9])<<8 | uint64(key[10])<<16 | uint64(key[11])<<24 | uint64(key[12])<<32 | uint64(key[13])<<40 | uint64(key[14])<<48 | uint64(key[15])<<56
+		key = key[16:]
+
+		h1, h2 = murmur3.Mix128Block(h1, h2, k1, k2)
+	}
+
+	// The tail is where Cassandra departs from the canonical hash: each
+	// byte is a sign extended Java byte, so high bytes smear ones across
+	// everything above them.
+	var k1, k2 uint64
+	for i := 8; i < len(key); i++ {
+		k2 ^= uint64(int8(key[i])) << (uint(i-8) * 8)
+	}
+	for i := 0; i < len(key) && i < 8; i++ {
+		k1 ^= uint64(int8(key[i])) << (uint(i) * 8)
+	}
+	h1, h2 = mixTail(h1, h2, k1, k2)
+
+	h1 ^= uint64(clen)
+	h2 ^= uint64(clen)
+
+	h1 += h2
+	h2 += h1
+
+	h1 = murmur3.Fmix64(h1)
+	h2 = murmur3.Fmix64(h2)
+
+	h1 += h2
+	h2 += h1
+
+	return h1, h2
+}
+
+// mixTail mixes the final, partial block k1, k2 into h1, h2, as murmur3 does
+// after its last whole block. A zero k1 or k2 leaves its half unchanged.
+func mixTail(h1, h2, k1, k2 uint64) (uint64, uint64) {
+	k1 *= c1
+	k1 = bits.RotateLeft64(k1, 31)
+	k1 *= c2
+	h1 ^= k1
+
+	k2 *= c2
+	k2 = bits.RotateLeft64(k2, 33)
+	k2 *= c1
+	h2 ^= k2
+
+	return h1, h2
+}
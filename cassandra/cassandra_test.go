@@ -0,0 +1,63 @@
+package cassandra
+
+import (
+	"bytes"
+	"encoding/hex"
+	"testing"
+	"testing/quick"
+
+	"github.com/twmb/murmur3"
+)
+
+func TestToken(t *testing.T) {
+	for _, test := range []struct {
+		key string // hex
+		exp int64
+	}{
+		{"", MinToken},             // Murmur3Partitioner.getToken of an empty key
+		{"30", 0x2ac9debed546a380}, // "0"
+		// A uuid and int composite key whose tail has high bytes; from
+		// gocql, checked against Cassandra.
+		{"00104327529fb645dd00b883ec39ae448bb800000400066a6b00", -9223371632693506265},
+	} {
+		key, _ := hex.DecodeString(test.key)
+		if got := Token(key); got != test.exp {
+			t.Errorf("Token(%s): got %d != exp %d", test.key, got, test.exp)
+		}
+	}
+}
+
+func TestCompositeKey(t *testing.T) {
+	uuid, _ := hex.DecodeString("4327529fb645dd00b883ec39ae448bb8")
+	id := []byte{0x00, 0x06, 0x6a, 0x6b}
+	exp, _ := hex.DecodeString("00104327529fb645dd00b883ec39ae448bb800000400066a6b00")
+	if got := CompositeKey(uuid, id); !bytes.Equal(got, exp) {
+		t.Errorf("CompositeKey: got %x != exp %x", got, exp)
+	}
+	if got, exp := CompositeToken(uuid, id), int64(-9223371632693506265); got != exp {
+		t.Errorf("CompositeToken: got %d != exp %d", got, exp)
+	}
+}
+
+func TestSignedTail(t *testing.T) {
+	// Without high tail bytes, Cassandra's hash is the canonical one.
+	f := func(data []byte) bool {
+		for i := len(data) &^ 15; i < len(data); i++ {
+			data[i] &= 0x7f
+		}
+		h1, h2 := Sum128(data)
+		c1, c2 := murmur3.Sum128(data)
+		return h1 == c1 && h2 == c2
+	}
+	if err := quick.Check(f, nil); err != nil {
+		t.Error(err)
+	}
+
+	// A high byte below the top of its word smears ones above it.
+	data := []byte{0x80, 0x01}
+	h1, h2 := Sum128(data)
+	c1, c2 := murmur3.Sum128(data)
+	if h1 == c1 && h2 == c2 {
+		t.Errorf("Sum128(%x): unexpectedly canonical", data)
+	}
+}
@@ -0,0 +1,241 @@
+// Command murmur3sum prints or checks murmur3 checksums, in the style of
+// sha256sum.
+//
+// Usage:
+//
+//	murmur3sum [flags] [file ...]
+//	murmur3sum [flags] -s string ...
+//	murmur3sum [flags] -c [checkfile ...]
+//
+// With no files, or when a file is -, standard input is read. Each checksum
+// is printed as the sum, two spaces and the file name.
+//
+// Flags:
+//
+//	-bits n      hash size: 32, 64 or 128 (default 128)
+//	-seed n      seed; 32 bit and x86 hashes require it to fit in 32 bits,
+//	             and the 128 bit hashes seed every lane with it
+//	-x86         with -bits 128, use MurmurHash3_x86_128
+//	-format f    sum format: hex, dec or base64 (default hex)
+//	-s           hash the arguments as strings rather than file names
+//	-c           read sums and names from the files and check them
+//	-quiet       with -c, do not print OK for each verified file
+//
+// Hex and base64 sums are of the big endian bytes of Hash.Sum; decimal sums
+// are of the same bytes as one unsigned integer.
+package main
+
+import (
+	"bufio"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"flag"
+	"fmt"
+	"hash"
+	"io"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"strings"
+
+	"github.com/twmb/murmur3"
+)
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdin, os.Stdout, os.Stderr))
+}
+
+type config struct {
+	bits   int
+	seed   uint64
+	x86    bool
+	format string
+}
+
+// newHash returns a fresh hasher for the configuration.
+func (c *config) newHash() hash.Hash {
+	switch {
+	case c.bits == 32:
+		return murmur3.SeedNew32(uint32(c.seed))
+	case c.bits == 64:
+		return murmur3.SeedNew64(c.seed)
+	case c.x86:
+		return murmur3.SeedNew128x86(uint32(c.seed))
+	default:
+		return murmur3.SeedNew128(c.seed, c.seed)
+	}
+}
+
+// encode formats a sum.
+func (c *config) encode(sum []byte) string {
+	switch c.format {
+	case "dec":
+		return new(big.Int).SetBytes(sum).String()
+	case "base64":
+		return base64.StdEncoding.EncodeToString(sum)
+	default:
+		return hex.EncodeToString(sum)
+	}
+}
+
+func run(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("murmur3sum", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	var (
+		c        config
+		strs     = fs.Bool("s", false, "hash the arguments as strings rather than file names")
+		check    = fs.Bool("c", false, "read sums and names from the files and check them")
+		quiet    = fs.Bool("quiet", false, "with -c, do not print OK for each verified file")
+		usageErr error
+	)
+	fs.IntVar(&c.bits, "bits", 128, "hash size: 32, 64 or 128")
+	fs.Uint64Var(&c.seed, "seed", 0, "seed")
+	fs.BoolVar(&c.x86, "x86", false, "with -bits 128, use MurmurHash3_x86_128")
+	fs.StringVar(&c.format, "format", "hex", "sum format: hex, dec or base64")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	switch {
+	case c.bits != 32 && c.bits != 64 && c.bits != 128:
+		usageErr = errors.New("-bits must be 32, 64 or 128")
+	case c.x86 && c.bits != 128:
+		usageErr = errors.New("-x86 requires -bits 128")
+	case (c.bits == 32 || c.x86) && c.seed > 1<<32-1:
+		usageErr = errors.New("-seed must fit in 32 bits for 32 bit and x86 hashes")
+	case c.format != "hex" && c.format != "dec" && c.format != "base64":
+		usageErr = errors.New("-format must be hex, dec or base64")
+	case *strs && *check:
+		usageErr = errors.New("-s and -c are mutually exclusive")
+	}
+	if usageErr != nil {
+		fmt.Fprintln(stderr, "murmur3sum:", usageErr)
+		return 2
+	}
+
+	if *strs {
+		for _, s := range fs.Args() {
+			h := c.newHash()
+			io.WriteString(h, s)
+			fmt.Fprintf(stdout, "%s  %s\n", c.encode(h.Sum(nil)), s)
+		}
+		return 0
+	}
+
+	names := fs.Args()
+	if len(names) == 0 {
+		names = []string{"-"}
+	}
+	status := 0
+	for _, name := range names {
+		if *check {
+			if !c.checkFile(name, stdin, stdout, stderr, *quiet) {
+				status = 1
+			}
+			continue
+		}
+		sum, err := c.sumFile(name, stdin)
+		if err != nil {
+			fmt.Fprintln(stderr, "murmur3sum:", err)
+			status = 1
+			continue
+		}
+		fmt.Fprintf(stdout, "%s  %s\n", sum, name)
+	}
+	return status
+}
+
+// open opens name, or returns stdin for -.
+func open(name string, stdin io.Reader) (io.ReadCloser, error) {
+	if name == "-" {
+		return ioutil.NopCloser(stdin), nil
+	}
+	return os.Open(name)
+}
+
+// sumFile returns the encoded sum of the file name.
+func (c *config) sumFile(name string, stdin io.Reader) (string, error) {
+	f, err := open(name, stdin)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	h := c.newHash()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return c.encode(h.Sum(nil)), nil
+}
+
+// sumsEqual reports whether the computed sum got matches the listed sum exp,
+// ignoring the case of hex digits as sha256sum does.
+func (c *config) sumsEqual(got, exp string) bool {
+	if c.format == "hex" {
+		return strings.EqualFold(got, exp)
+	}
+	return got == exp
+}
+
+// checkFile verifies every "sum  name" line of the check file name, returning
+// whether all lines were well formed and matched. Like sha256sum, a file with
+// no well formed lines at all fails.
+func (c *config) checkFile(name string, stdin io.Reader, stdout, stderr io.Writer, quiet bool) bool {
+	f, err := open(name, stdin)
+	if err != nil {
+		fmt.Fprintln(stderr, "murmur3sum:", err)
+		return false
+	}
+	defer f.Close()
+
+	var checked, bad, failed, unreadable int
+	scanner := bufio.NewScanner(f)
+	for line := 1; scanner.Scan(); line++ {
+		text := scanner.Text()
+		if text == "" {
+			continue
+		}
+		// sha256sum separates with a space and then either a second
+		// space or, for binary mode, an asterisk.
+		i := strings.Index(text, " ")
+		if i <= 0 || i+2 > len(text) || text[i+1] != ' ' && text[i+1] != '*' {
+			fmt.Fprintf(stderr, "murmur3sum: %s: %d: improperly formatted checksum line\n", name, line)
+			bad++
+			continue
+		}
+		exp, file := text[:i], text[i+2:]
+		checked++
+
+		got, err := c.sumFile(file, stdin)
+		switch {
+		case err != nil:
+			fmt.Fprintln(stderr, "murmur3sum:", err)
+			fmt.Fprintf(stdout, "%s: FAILED open or read\n", file)
+			unreadable++
+		case !c.sumsEqual(got, exp):
+			fmt.Fprintf(stdout, "%s: FAILED\n", file)
+			failed++
+		case !quiet:
+			fmt.Fprintf(stdout, "%s: OK\n", file)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		fmt.Fprintln(stderr, "murmur3sum:", err)
+		return false
+	}
+
+	if checked == 0 {
+		fmt.Fprintf(stderr, "murmur3sum: %s: no properly formatted checksum lines found\n", name)
+		return false
+	}
+	if bad > 0 {
+		fmt.Fprintf(stderr, "murmur3sum: WARNING: %d line(s) improperly formatted\n", bad)
+	}
+	if unreadable > 0 {
+		fmt.Fprintf(stderr, "murmur3sum: WARNING: %d listed file(s) could not be read\n", unreadable)
+	}
+	if failed > 0 {
+		fmt.Fprintf(stderr, "murmur3sum: WARNING: %d computed checksum(s) did NOT match\n", failed)
+	}
+	return bad == 0 && unreadable == 0 && failed == 0
+}
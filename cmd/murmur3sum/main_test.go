@@ -0,0 +1,137 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"hash"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/twmb/murmur3"
+)
+
+func runArgs(stdin string, args ...string) (code int, stdout, stderr string) {
+	var out, errOut bytes.Buffer
+	code = run(args, strings.NewReader(stdin), &out, &errOut)
+	return code, out.String(), errOut.String()
+}
+
+func TestSums(t *testing.T) {
+	for _, test := range []struct {
+		args []string
+		exp  string
+	}{
+		{[]string{"-bits", "32"}, "248bfa47  -\n"},
+		{[]string{"-bits", "32", "-format", "dec"}, "613153351  -\n"},
+		{[]string{"-bits", "64"}, "cbd8a7b341bd9b02  -\n"},
+		{[]string{"-bits", "64", "-format", "base64"}, "y9ins0G9mwI=  -\n"},
+		{nil, "cbd8a7b341bd9b025b1e906a48ae1d19  -\n"},
+		{[]string{"-seed", "1", "-bits", "32", "-s", "hello"},
+			fmt.Sprintf("%08x  hello\n", murmur3.SeedStringSum32(1, "hello"))},
+		{[]string{"-seed", "1", "-s", "hello"},
+			fmt.Sprintf("%x  hello\n", sum(murmur3.SeedNew128(1, 1), "hello"))},
+		{[]string{"-x86", "-s", "a", "b"},
+			fmt.Sprintf("%x  a\n%x  b\n", sum(murmur3.New128x86(), "a"), sum(murmur3.New128x86(), "b"))},
+	} {
+		code, out, errOut := runArgs("hello", test.args...)
+		if code != 0 || out != test.exp {
+			t.Errorf("%v: got %d %q %q, want %q", test.args, code, out, errOut, test.exp)
+		}
+	}
+}
+
+func sum(h hash.Hash, s string) []byte {
+	h.Write([]byte(s))
+	return h.Sum(nil)
+}
+
+func TestFlagErrors(t *testing.T) {
+	for _, args := range [][]string{
+		{"-bits", "16"},
+		{"-bits", "64", "-x86"},
+		{"-bits", "32", "-seed", "4294967296"},
+		{"-x86", "-seed", "4294967296"},
+		{"-format", "octal"},
+		{"-s", "-c"},
+		{"-nope"},
+	} {
+		if code, _, _ := runArgs("", args...); code != 2 {
+			t.Errorf("%v: exit code %d, want 2", args, code)
+		}
+	}
+}
+
+func TestFilesAndCheck(t *testing.T) {
+	dir, err := ioutil.TempDir("", "murmur3sum")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	a, b := filepath.Join(dir, "a"), filepath.Join(dir, "b")
+	ioutil.WriteFile(a, []byte("hello"), 0644)
+	ioutil.WriteFile(b, []byte("world"), 0644)
+
+	code, sums, _ := runArgs("", "-x86", a, b)
+	if code != 0 || strings.Count(sums, "\n") != 2 || !strings.HasSuffix(sums, "  "+b+"\n") {
+		t.Fatalf("sums: got %d %q", code, sums)
+	}
+
+	check := filepath.Join(dir, "sums")
+	ioutil.WriteFile(check, []byte(sums), 0644)
+	code, out, _ := runArgs("", "-x86", "-c", check)
+	if exp := a + ": OK\n" + b + ": OK\n"; code != 0 || out != exp {
+		t.Errorf("check: got %d %q, want %q", code, out, exp)
+	}
+	if code, out, _ := runArgs("", "-x86", "-c", "-quiet", check); code != 0 || out != "" {
+		t.Errorf("quiet check: got %d %q", code, out)
+	}
+	// The check file can come from stdin, and binary markers are allowed.
+	if code, _, _ := runArgs(strings.Replace(sums, "  ", " *", -1), "-x86", "-c"); code != 0 {
+		t.Errorf("check from stdin: exit code %d", code)
+	}
+
+	// Hex sums match regardless of case, base64 sums only exactly.
+	upperSums := func(sums string) string {
+		lines := strings.SplitAfter(sums, "\n")
+		for i, line := range lines {
+			if j := strings.Index(line, "  "); j > 0 {
+				lines[i] = strings.ToUpper(line[:j]) + line[j:]
+			}
+		}
+		return strings.Join(lines, "")
+	}
+	if code, out, _ := runArgs(upperSums(sums), "-x86", "-c"); code != 0 || strings.Contains(out, "FAILED") {
+		t.Errorf("uppercase hex check: got %d %q", code, out)
+	}
+	_, b64, _ := runArgs("", "-x86", "-format", "base64", a)
+	if code, out, _ := runArgs(upperSums(b64), "-x86", "-format", "base64", "-c"); code != 1 || !strings.Contains(out, "FAILED") {
+		t.Errorf("uppercased base64 check: got %d %q", code, out)
+	}
+
+	// A checksum of the wrong variant, a modified file, a missing file and
+	// a malformed line all fail.
+	if code, _, _ := runArgs("", "-c", check); code != 1 {
+		t.Errorf("check of x86 sums as x64: exit code %d", code)
+	}
+	ioutil.WriteFile(b, []byte("World"), 0644)
+	code, out, errOut := runArgs("", "-x86", "-c", check)
+	if code != 1 || !strings.Contains(out, b+": FAILED\n") || !strings.Contains(errOut, "1 computed checksum(s) did NOT match") {
+		t.Errorf("modified file: got %d %q %q", code, out, errOut)
+	}
+	os.Remove(b)
+	if code, out, _ := runArgs("", "-x86", "-c", check); code != 1 || !strings.Contains(out, "FAILED open or read") {
+		t.Errorf("missing file: got %d %q", code, out)
+	}
+	if code, _, errOut := runArgs("nonsense\n", "-c"); code != 1 || !strings.Contains(errOut, "improperly formatted") {
+		t.Errorf("malformed line: got %d %q", code, errOut)
+	}
+	for _, in := range []string{"", "\n\n", "nonsense\n"} {
+		if code, _, errOut := runArgs(in, "-c"); code != 1 || !strings.Contains(errOut, "-: no properly formatted checksum lines found") {
+			t.Errorf("check of %q: got %d %q", in, code, errOut)
+		}
+	}
+}
@@ -0,0 +1,238 @@
+// Package cms provides a count-min sketch for estimating the frequencies of
+// items in a stream, and a top-k tracker layered on it.
+//
+// Each item is hashed once with murmur3.StringSum128, and its column in each
+// of the d rows is derived from the two halves of that sum: row i uses
+// column h1 + i*h2 modulo the width.
+package cms
+
+import (
+	"encoding"
+	"encoding/binary"
+	"errors"
+	"math"
+
+	"github.com/twmb/murmur3"
+)
+
+// Make sure interfaces are correctly implemented.
+var (
+	_ encoding.BinaryMarshaler   = new(Sketch)
+	_ encoding.BinaryUnmarshaler = new(Sketch)
+)
+
+var (
+	errShape = errors.New("cms: sketches have different shapes")
+	errState = errors.New("cms: invalid sketch encoding")
+)
+
+const magic = "mm3c\x01"
+
+// Sketch is a count-min sketch of d rows of w counters. Estimates never
+// undercount; with w = ceil(e/epsilon) and d = ceil(ln(1/delta)), an estimate
+// exceeds the true count by more than epsilon times the total count with
+// probability at most delta.
+//
+// The zero value is not usable; create sketches with New or
+// NewWithEstimates. A Sketch is not safe for concurrent use.
+type Sketch struct {
+	w, d         uint64
+	conservative bool
+	total        uint64
+	counts       []uint64 // row major
+}
+
+// New returns a sketch of depth rows of width counters. Both are raised to at
+// least one.
+//
+// New panics if the sketch would need more counters than fit in an int.
+func New(width, depth uint) *Sketch {
+	if width < 1 {
+		width = 1
+	}
+	if depth < 1 {
+		depth = 1
+	}
+	if width > (^uint(0)>>1)/depth {
+		panic("cms: width and depth need too many counters")
+	}
+	return &Sketch{
+		w:      uint64(width),
+		d:      uint64(depth),
+		counts: make([]uint64, width*depth),
+	}
+}
+
+// NewWithEstimates returns a sketch whose estimates exceed true counts by at
+// most epsilon times the total count with probability 1-delta.
+//
+// NewWithEstimates panics if epsilon is not positive, if delta is not strictly
+// between 0 and 1, or if the sketch would need more counters than fit in an
+// int.
+func NewWithEstimates(epsilon, delta float64) *Sketch {
+	if !(epsilon > 0) {
+		panic("cms: epsilon must be positive")
+	}
+	if !(delta > 0 && delta < 1) {
+		panic("cms: delta must be strictly between 0 and 1")
+	}
+	w := math.Ceil(math.E / epsilon)
+	d := math.Ceil(math.Log(1 / delta))
+	if d < 1 {
+		d = 1
+	}
+	if w*d >= float64(^uint(0)>>1) {
+		panic("cms: epsilon and delta need too many counters")
+	}
+	return New(uint(w), uint(d))
+}
+
+// SetConservative enables or disables conservative update. A conservative
+// sketch only raises the counters of an item that are below its new estimate,
+// which reduces overestimation at the cost of slower adds. Sketches that are
+// merged remain valid upper bounds regardless of mode.
+func (s *Sketch) SetConservative(conservative bool) { s.conservative = conservative }
+
+// Width returns the number of counters per row.
+func (s *Sketch) Width() uint { return uint(s.w) }
+
+// Depth returns the number of rows.
+func (s *Sketch) Depth() uint { return uint(s.d) }
+
+// Total returns the sum of all counts added.
+func (s *Sketch) Total() uint64 { return s.total }
+
+func (s *Sketch) add(h1, h2, count uint64) uint64 {
+	s.total += count
+	if !s.conservative {
+		est := uint64(math.MaxUint64)
+		for i := uint64(0); i < s.d; i++ {
+			c := &s.counts[i*s.w+(h1+i*h2)%s.w]
+			*c += count
+			if *c < est {
+				est = *c
+			}
+		}
+		return est
+	}
+	est := s.estimate(h1, h2) + count
+	for i := uint64(0); i < s.d; i++ {
+		if c := &s.counts[i*s.w+(h1+i*h2)%s.w]; *c < est {
+			*c = est
+		}
+	}
+	return est
+}
+
+func (s *Sketch) estimate(h1, h2 uint64) uint64 {
+	est := uint64(math.MaxUint64)
+	for i := uint64(0); i < s.d; i++ {
+		if c := s.counts[i*s.w+(h1+i*h2)%s.w]; c < est {
+			est = c
+		}
+	}
+	return est
+}
+
+// Add adds count occurrences of data and returns the new estimate for it.
+func (s *Sketch) Add(data []byte, count uint64) uint64 {
+	h1, h2 := murmur3.Sum128(data)
+	return s.add(h1, h2, count)
+}
+
+// AddString is the string version of Add.
+func (s *Sketch) AddString(data string, count uint64) uint64 {
+	h1, h2 := murmur3.StringSum128(data)
+	return s.add(h1, h2, count)
+}
+
+// Estimate returns the estimated count of data.
+func (s *Sketch) Estimate(data []byte) uint64 {
+	return s.estimate(murmur3.Sum128(data))
+}
+
+// EstimateString is the string version of Estimate.
+func (s *Sketch) EstimateString(data string) uint64 {
+	return s.estimate(murmur3.StringSum128(data))
+}
+
+// Merge adds every count of other to s. The sketches must have the same width
+// and depth.
+func (s *Sketch) Merge(other *Sketch) error {
+	if s.w != other.w || s.d != other.d {
+		return errShape
+	}
+	for i, c := range other.counts {
+		s.counts[i] += c
+	}
+	s.total += other.total
+	return nil
+}
+
+// MarshalBinary encodes the sketch's shape, mode and counters.
+func (s *Sketch) MarshalBinary() ([]byte, error) {
+	b := make([]byte, 0, len(magic)+1+3*binary.MaxVarintLen64+len(s.counts))
+	b = append(b, magic...)
+	if s.conservative {
+		b = append(b, 1)
+	} else {
+		b = append(b, 0)
+	}
+	b = appendUvarint(b, s.w)
+	b = appendUvarint(b, s.d)
+	b = appendUvarint(b, s.total)
+	for _, c := range s.counts {
+		b = appendUvarint(b, c)
+	}
+	return b, nil
+}
+
+// UnmarshalBinary replaces the sketch with one encoded by MarshalBinary.
+func (s *Sketch) UnmarshalBinary(b []byte) error {
+	if len(b) < len(magic)+1 || string(b[:len(magic)]) != magic || b[len(magic)] > 1 {
+		return errState
+	}
+	conservative := b[len(magic)] == 1
+	b = b[len(magic)+1:]
+
+	var w, d, total uint64
+	var ok bool
+	if w, b, ok = consumeUvarint(b); !ok || w < 1 {
+		return errState
+	}
+	if d, b, ok = consumeUvarint(b); !ok || d < 1 {
+		return errState
+	}
+	if total, b, ok = consumeUvarint(b); !ok {
+		return errState
+	}
+	// Every counter takes at least a byte, which bounds the allocation
+	// below by the input size.
+	if w > uint64(len(b)) || d > uint64(len(b))/w {
+		return errState
+	}
+	counts := make([]uint64, w*d)
+	for i := range counts {
+		if counts[i], b, ok = consumeUvarint(b); !ok {
+			return errState
+		}
+	}
+	if len(b) != 0 {
+		return errState
+	}
+	*s = Sketch{w: w, d: d, conservative: conservative, total: total, counts: counts}
+	return nil
+}
+
+func appendUvarint(b []byte, x uint64) []byte {
+	var buf [binary.MaxVarintLen64]byte
+	return append(b, buf[:binary.PutUvarint(buf[:], x)]...)
+}
+
+func consumeUvarint(b []byte) (uint64, []byte, bool) {
+	x, n := binary.Uvarint(b)
+	if n <= 0 {
+		return 0, nil, false
+	}
+	return x, b[n:], true
+}
@@ -0,0 +1,196 @@
+package cms
+
+import (
+	"bytes"
+	"math"
+	"math/rand"
+	"strconv"
+	"testing"
+)
+
+// zipf returns a skewed stream of keys and their true counts.
+func zipf(n int) ([]string, map[string]uint64) {
+	z := rand.NewZipf(rand.New(rand.NewSource(1)), 1.2, 1, 10000)
+	stream := make([]string, n)
+	counts := make(map[string]uint64)
+	for i := range stream {
+		stream[i] = strconv.FormatUint(z.Uint64(), 10)
+		counts[stream[i]]++
+	}
+	return stream, counts
+}
+
+func TestNewWithEstimates(t *testing.T) {
+	s := NewWithEstimates(0.01, 0.01)
+	if s.Width() != 272 || s.Depth() != 5 {
+		t.Errorf("NewWithEstimates(0.01, 0.01) shape = %d, %d; want 272, 5", s.Width(), s.Depth())
+	}
+	for _, params := range [][2]float64{
+		{0, 0.01}, {-1, 0.01}, {math.NaN(), 0.01}, {1e-300, 0.01},
+		{0.01, 0}, {0.01, 1}, {0.01, -0.5}, {0.01, math.NaN()},
+	} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("NewWithEstimates(%v, %v) did not panic", params[0], params[1])
+				}
+			}()
+			NewWithEstimates(params[0], params[1])
+		}()
+	}
+}
+
+func TestNewOverflow(t *testing.T) {
+	const maxInt = ^uint(0) >> 1
+	for _, shape := range [][2]uint{{maxInt, 2}, {maxInt/3 + 1, 3}, {^uint(0), 1}} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("New(%d, %d) did not panic", shape[0], shape[1])
+				}
+			}()
+			New(shape[0], shape[1])
+		}()
+	}
+}
+
+func TestEstimate(t *testing.T) {
+	stream, counts := zipf(100000)
+	const epsilon = 0.001
+	for _, conservative := range []bool{false, true} {
+		s := NewWithEstimates(epsilon, 0.01)
+		s.SetConservative(conservative)
+		for i, key := range stream {
+			if i%2 == 0 {
+				s.AddString(key, 1)
+			} else {
+				s.Add([]byte(key), 1)
+			}
+		}
+		if s.Total() != uint64(len(stream)) {
+			t.Errorf("conservative %v: total %d != %d", conservative, s.Total(), len(stream))
+		}
+		var over int
+		for key, exp := range counts {
+			got := s.EstimateString(key)
+			if got < exp {
+				t.Fatalf("conservative %v: %s undercounted: %d < %d", conservative, key, got, exp)
+			}
+			if got != s.Estimate([]byte(key)) {
+				t.Fatalf("conservative %v: Estimate and EstimateString differ for %s", conservative, key)
+			}
+			if float64(got-exp) > epsilon*float64(len(stream)) {
+				over++
+			}
+		}
+		if over > len(counts)/100 {
+			t.Errorf("conservative %v: %d of %d estimates beyond the error bound", conservative, over, len(counts))
+		}
+	}
+}
+
+func TestConservativeTighter(t *testing.T) {
+	stream, counts := zipf(50000)
+	plain, cons := New(200, 4), New(200, 4)
+	cons.SetConservative(true)
+	for _, key := range stream {
+		plain.AddString(key, 1)
+		cons.AddString(key, 1)
+	}
+	var plainErr, consErr uint64
+	for key, exp := range counts {
+		p, c := plain.EstimateString(key), cons.EstimateString(key)
+		if c > p {
+			t.Fatalf("%s: conservative estimate %d > plain %d", key, c, p)
+		}
+		plainErr += p - exp
+		consErr += c - exp
+	}
+	if consErr >= plainErr {
+		t.Errorf("conservative total error %d not below plain %d", consErr, plainErr)
+	}
+}
+
+func TestMerge(t *testing.T) {
+	a, b, all := New(100, 3), New(100, 3), New(100, 3)
+	for i := 0; i < 1000; i++ {
+		key := strconv.Itoa(i % 37)
+		if i%3 == 0 {
+			a.AddString(key, 2)
+		} else {
+			b.AddString(key, 2)
+		}
+		all.AddString(key, 2)
+	}
+	if err := a.Merge(b); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 37; i++ {
+		key := strconv.Itoa(i)
+		if got, exp := a.EstimateString(key), all.EstimateString(key); got != exp {
+			t.Errorf("%s: merged estimate %d != %d", key, got, exp)
+		}
+	}
+	if a.Total() != all.Total() {
+		t.Errorf("merged total %d != %d", a.Total(), all.Total())
+	}
+	if err := a.Merge(New(100, 4)); err == nil {
+		t.Error("merge of different depths succeeded")
+	}
+}
+
+func TestMarshalBinary(t *testing.T) {
+	s := New(64, 3)
+	s.SetConservative(true)
+	for i := 0; i < 500; i++ {
+		s.AddString(strconv.Itoa(i%50), uint64(i))
+	}
+	b, err := s.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var u Sketch
+	if err := u.UnmarshalBinary(b); err != nil {
+		t.Fatal(err)
+	}
+	if b2, _ := u.MarshalBinary(); !bytes.Equal(b, b2) {
+		t.Error("encoding not stable across a round trip")
+	}
+	if u.Width() != 64 || u.Depth() != 3 || !u.conservative || u.Total() != s.Total() {
+		t.Errorf("unmarshaled sketch differs: %d x %d, conservative %v, total %d",
+			u.Width(), u.Depth(), u.conservative, u.Total())
+	}
+	for i := 0; i < 50; i++ {
+		key := strconv.Itoa(i)
+		if got, exp := u.EstimateString(key), s.EstimateString(key); got != exp {
+			t.Errorf("%s: unmarshaled estimate %d != %d", key, got, exp)
+		}
+	}
+	for _, bad := range [][]byte{nil, b[:len(b)-1], append(b, 0)} {
+		if err := u.UnmarshalBinary(bad); err == nil {
+			t.Errorf("UnmarshalBinary(%d bytes) succeeded", len(bad))
+		}
+	}
+}
+
+func TestTopK(t *testing.T) {
+	stream, counts := zipf(100000)
+	top := NewTopK(10, NewWithEstimates(0.001, 0.01))
+	for _, key := range stream {
+		top.Add(key, 1)
+	}
+	items := top.Items()
+	if len(items) != 10 {
+		t.Fatalf("got %d items, want 10", len(items))
+	}
+	// Zipf ranks map directly to keys: 0 is the most frequent, then 1, and
+	// so on, with the leaders far enough apart to be found exactly.
+	for i, it := range items {
+		if it.Key != strconv.Itoa(i) {
+			t.Errorf("rank %d: got %s, want %d", i, it.Key, i)
+		}
+		if it.Count < counts[it.Key] {
+			t.Errorf("rank %d: count %d below true %d", i, it.Count, counts[it.Key])
+		}
+	}
+}
@@ -0,0 +1,101 @@
+package cms
+
+import (
+	"container/heap"
+	"sort"
+)
+
+// Item is an item tracked by TopK and its estimated count.
+type Item struct {
+	Key   string
+	Count uint64
+}
+
+// TopK tracks the k items with the highest estimated counts in a stream,
+// using a Sketch for the estimates and a min-heap of the current leaders.
+//
+// A TopK is not safe for concurrent use.
+type TopK struct {
+	k      int
+	sketch *Sketch
+	heap   itemHeap
+}
+
+// NewTopK returns a tracker of the k most frequent items, estimating counts
+// with sketch. The sketch may already hold counts, but items added to it
+// directly are only tracked once they are next added through the TopK.
+func NewTopK(k int, sketch *Sketch) *TopK {
+	if k < 1 {
+		k = 1
+	}
+	return &TopK{
+		k:      k,
+		sketch: sketch,
+		heap:   itemHeap{index: make(map[string]int, k)},
+	}
+}
+
+// Sketch returns the sketch underlying the tracker.
+func (t *TopK) Sketch() *Sketch { return t.sketch }
+
+// Add adds count occurrences of key and returns its new estimate.
+func (t *TopK) Add(key string, count uint64) uint64 {
+	est := t.sketch.AddString(key, count)
+	h := &t.heap
+	if i, ok := h.index[key]; ok {
+		h.items[i].Count = est
+		heap.Fix(h, i)
+		return est
+	}
+	if len(h.items) < t.k {
+		heap.Push(h, Item{key, est})
+		return est
+	}
+	if est > h.items[0].Count {
+		delete(h.index, h.items[0].Key)
+		h.items[0] = Item{key, est}
+		h.index[key] = 0
+		heap.Fix(h, 0)
+	}
+	return est
+}
+
+// Items returns the tracked items, highest count first, breaking ties by key.
+func (t *TopK) Items() []Item {
+	items := append([]Item(nil), t.heap.items...)
+	sort.Slice(items, func(i, j int) bool {
+		if items[i].Count != items[j].Count {
+			return items[i].Count > items[j].Count
+		}
+		return items[i].Key < items[j].Key
+	})
+	return items
+}
+
+// itemHeap is a min-heap of items by count that tracks each item's position.
+type itemHeap struct {
+	items []Item
+	index map[string]int
+}
+
+func (h *itemHeap) Len() int           { return len(h.items) }
+func (h *itemHeap) Less(i, j int) bool { return h.items[i].Count < h.items[j].Count }
+
+func (h *itemHeap) Swap(i, j int) {
+	h.items[i], h.items[j] = h.items[j], h.items[i]
+	h.index[h.items[i].Key] = i
+	h.index[h.items[j].Key] = j
+}
+
+func (h *itemHeap) Push(x interface{}) {
+	it := x.(Item)
+	h.index[it.Key] = len(h.items)
+	h.items = append(h.items, it)
+}
+
+func (h *itemHeap) Pop() interface{} {
+	it := h.items[len(h.items)-1]
+	h.items = h.items[:len(h.items)-1]
+	delete(h.index, it.Key)
+	return it
+}
@@ -0,0 +1,163 @@
+// Package consistent maps keys to buckets or nodes such that changing the
+// number of buckets or the set of nodes moves as few keys as possible.
+//
+// JumpHash suits numbered, append-only buckets such as shards. Rendezvous
+// suits named nodes that may come and go in any order and that may carry
+// different weights.
+package consistent
+
+import (
+	"math"
+	"sort"
+
+	"github.com/twmb/murmur3"
+)
+
+// JumpHash returns the bucket in [0, buckets) for key, per Lamping and
+// Veach's jump consistent hash. Growing buckets from n to n+1 moves only
+// about 1/(n+1) of keys, all of them to the new bucket n.
+//
+// JumpHash panics if buckets is not positive.
+func JumpHash(key uint64, buckets int) int {
+	if buckets < 1 {
+		panic("consistent: JumpHash requires at least one bucket")
+	}
+	var b, j int64 = -1, 0
+	for j < int64(buckets) {
+		b = j
+		key = key*2862933555777941757 + 1
+		j = int64(float64(b+1) * (float64(int64(1)<<31) / float64(key>>33+1)))
+	}
+	return int(b)
+}
+
+// JumpHashString is JumpHash over the StringSum64 of key.
+func JumpHashString(key string, buckets int) int {
+	return JumpHash(murmur3.StringSum64(key), buckets)
+}
+
+// Rendezvous is a highest random weight hash over a set of named nodes. Each
+// key goes to the node scoring highest for it, where a node's score for a key
+// is derived from SeedStringSum64 of the key, seeded by the node's name. A
+// node's share of keys is proportional to its weight.
+//
+// Adding a node moves to it only the keys it now wins, and removing a node
+// moves only the keys it held. A Rendezvous is not safe for concurrent use.
+type Rendezvous struct {
+	nodes []rendezvousNode
+}
+
+type rendezvousNode struct {
+	name   string
+	seed   uint64
+	weight float64
+}
+
+// NewRendezvous returns a Rendezvous over the given nodes, each of weight 1.
+func NewRendezvous(nodes ...string) *Rendezvous {
+	r := new(Rendezvous)
+	for _, node := range nodes {
+		r.Add(node)
+	}
+	return r
+}
+
+// Add adds node with a weight of 1, or resets its weight to 1 if it exists.
+func (r *Rendezvous) Add(node string) { r.AddWeighted(node, 1) }
+
+// AddWeighted adds node with the given weight, or updates the weight of an
+// existing node. Nodes with a weight that is not positive are removed.
+func (r *Rendezvous) AddWeighted(node string, weight float64) {
+	if !(weight > 0) {
+		r.Remove(node)
+		return
+	}
+	for i := range r.nodes {
+		if r.nodes[i].name == node {
+			r.nodes[i].weight = weight
+			return
+		}
+	}
+	r.nodes = append(r.nodes, rendezvousNode{
+		name:   node,
+		seed:   murmur3.StringSum64(node),
+		weight: weight,
+	})
+}
+
+// Remove removes node, if it exists.
+func (r *Rendezvous) Remove(node string) {
+	for i := range r.nodes {
+		if r.nodes[i].name == node {
+			r.nodes = append(r.nodes[:i], r.nodes[i+1:]...)
+			return
+		}
+	}
+}
+
+// Len returns the number of nodes.
+func (r *Rendezvous) Len() int { return len(r.nodes) }
+
+// Nodes returns the names of all nodes in the order they were added.
+func (r *Rendezvous) Nodes() []string {
+	names := make([]string, len(r.nodes))
+	for i, n := range r.nodes {
+		names[i] = n.name
+	}
+	return names
+}
+
+// score returns the weighted score of n for key, using the logarithmic method
+// of Schindelhauer and Schomaker: the hash is mapped to u in (0, 1) and scored
+// as -weight/ln(u), which for equal weights orders nodes exactly as their
+// hashes do.
+func (n *rendezvousNode) score(key string) float64 {
+	h := murmur3.SeedStringSum64(n.seed, key)
+	u := (float64(h>>11) + 0.5) / (1 << 53)
+	return -n.weight / math.Log(u)
+}
+
+// Get returns the node for key, or the empty string if there are no nodes.
+func (r *Rendezvous) Get(key string) string {
+	var (
+		best      string
+		bestScore = math.Inf(-1)
+	)
+	for i := range r.nodes {
+		n := &r.nodes[i]
+		if s := n.score(key); s > bestScore || s == bestScore && n.name < best {
+			best, bestScore = n.name, s
+		}
+	}
+	return best
+}
+
+// GetN returns up to n distinct nodes for key, best first. The first node is
+// always Get(key), which makes GetN suitable for choosing replicas.
+func (r *Rendezvous) GetN(key string, n int) []string {
+	if n > len(r.nodes) {
+		n = len(r.nodes)
+	}
+	if n <= 0 {
+		return nil
+	}
+	type scored struct {
+		name  string
+		score float64
+	}
+	all := make([]scored, len(r.nodes))
+	for i := range r.nodes {
+		all[i] = scored{r.nodes[i].name, r.nodes[i].score(key)}
+	}
+	sort.Slice(all, func(i, j int) bool {
+		if all[i].score != all[j].score {
+			return all[i].score > all[j].score
+		}
+		return all[i].name < all[j].name
+	})
+	names := make([]string, n)
+	for i := range names {
+		names[i] = all[i].name
+	}
+	return names
+}
@@ -0,0 +1,194 @@
+package consistent
+
+import (
+	"math"
+	"strconv"
+	"testing"
+)
+
+func TestJumpHash(t *testing.T) {
+	// Vectors shared by the common implementations of the paper's
+	// algorithm.
+	for _, test := range []struct {
+		key     uint64
+		buckets int
+		exp     int
+	}{
+		{1, 1, 0},
+		{42, 57, 43},
+		{0xdead10cc, 1, 0},
+		{0xdead10cc, 666, 361},
+		{256, 1024, 520},
+	} {
+		if got := JumpHash(test.key, test.buckets); got != test.exp {
+			t.Errorf("JumpHash(%d, %d): got %d != exp %d", test.key, test.buckets, got, test.exp)
+		}
+	}
+}
+
+func TestJumpHashMovement(t *testing.T) {
+	const keys = 10000
+	for buckets := 1; buckets < 20; buckets++ {
+		var moved int
+		for i := 0; i < keys; i++ {
+			key := strconv.Itoa(i)
+			before, after := JumpHashString(key, buckets), JumpHashString(key, buckets+1)
+			if before == after {
+				continue
+			}
+			if after != buckets {
+				t.Fatalf("key %s moved from %d to old bucket %d", key, before, after)
+			}
+			moved++
+		}
+		if exp := keys / (buckets + 1); math.Abs(float64(moved-exp)) > 0.2*float64(exp) {
+			t.Errorf("%d to %d buckets: moved %d keys, expected about %d", buckets, buckets+1, moved, exp)
+		}
+	}
+}
+
+func TestRendezvousMovement(t *testing.T) {
+	r := NewRendezvous("a", "b", "c", "d")
+	const keys = 10000
+	before := make([]string, keys)
+	counts := make(map[string]int)
+	for i := range before {
+		before[i] = r.Get(strconv.Itoa(i))
+		counts[before[i]]++
+	}
+	for node, n := range counts {
+		if math.Abs(float64(n)-keys/4) > keys/4*0.1 {
+			t.Errorf("node %s holds %d of %d keys", node, n, keys)
+		}
+	}
+
+	r.Add("e")
+	for i, prev := range before {
+		if now := r.Get(strconv.Itoa(i)); now != prev && now != "e" {
+			t.Fatalf("adding e moved key %d from %s to %s", i, prev, now)
+		}
+	}
+
+	r.Remove("e")
+	r.Remove("b")
+	for i, prev := range before {
+		now := r.Get(strconv.Itoa(i))
+		if prev != "b" && now != prev {
+			t.Fatalf("removing b moved key %d from %s to %s", i, prev, now)
+		}
+		if now == "b" {
+			t.Fatalf("key %d still maps to removed node b", i)
+		}
+	}
+}
+
+func TestRendezvousWeighted(t *testing.T) {
+	r := new(Rendezvous)
+	r.AddWeighted("small", 1)
+	r.AddWeighted("large", 3)
+	const keys = 20000
+	var large int
+	for i := 0; i < keys; i++ {
+		if r.Get(strconv.Itoa(i)) == "large" {
+			large++
+		}
+	}
+	if share := float64(large) / keys; math.Abs(share-0.75) > 0.02 {
+		t.Errorf("large node share %.3f, expected 0.75", share)
+	}
+
+	r.AddWeighted("large", 0)
+	if r.Len() != 1 || r.Get("x") != "small" {
+		t.Errorf("zero weight did not remove node: %v", r.Nodes())
+	}
+}
+
+func TestRendezvousGetN(t *testing.T) {
+	if got := new(Rendezvous).Get("x"); got != "" {
+		t.Errorf("empty Rendezvous Get: got %q", got)
+	}
+	r := NewRendezvous("a", "b", "c")
+	for i := 0; i < 100; i++ {
+		key := strconv.Itoa(i)
+		got := r.GetN(key, 5)
+		if len(got) != 3 || got[0] != r.Get(key) {
+			t.Fatalf("GetN(%s, 5) = %v, Get = %s", key, got, r.Get(key))
+		}
+		if got[0] == got[1] || got[1] == got[2] || got[0] == got[2] {
+			t.Fatalf("GetN(%s, 5) = %v has duplicates", key, got)
+		}
+	}
+}
+
+func TestRing(t *testing.T) {
+	for _, hash := range []RingHash{RingHash32, RingHash64} {
+		r := NewRing(160, hash)
+		if got := r.Get("x"); got != "" {
+			t.Errorf("empty Ring Get: got %q", got)
+		}
+		r.Add("a", "b", "c", "d")
+		r.Add("a") // no-op
+
+		// A virtual point's own name hashes exactly onto the point, so
+		// it belongs to the point's member.
+		for _, m := range []string{"a", "b", "c", "d"} {
+			for i := 0; i < 160; i++ {
+				if got := r.Get(strconv.Itoa(i) + m); got != m {
+					t.Fatalf("hash %d: point %d of %s maps to %s", hash, i, m, got)
+				}
+			}
+		}
+
+		const keys = 10000
+		before := make([]string, keys)
+		counts := make(map[string]int)
+		for i := range before {
+			before[i] = r.Get(strconv.Itoa(i))
+			counts[before[i]]++
+		}
+		for m, n := range counts {
+			if math.Abs(float64(n)-keys/4) > keys/4*0.4 {
+				t.Errorf("hash %d: member %s holds %d of %d keys", hash, m, n, keys)
+			}
+		}
+
+		r.Remove("b")
+		for i, prev := range before {
+			if now := r.Get(strconv.Itoa(i)); prev != "b" && now != prev || now == "b" {
+				t.Fatalf("hash %d: removing b moved key %d from %s to %s", hash, i, prev, now)
+			}
+		}
+		if got := r.Members(); len(got) != 3 || got[0] != "a" || got[1] != "c" || got[2] != "d" {
+			t.Errorf("hash %d: Members = %v", hash, got)
+		}
+
+		for i := 0; i < 100; i++ {
+			key := strconv.Itoa(i)
+			got := r.GetN(key, 10)
+			if len(got) != 3 || got[0] != r.Get(key) ||
+				got[0] == got[1] || got[1] == got[2] || got[0] == got[2] {
+				t.Fatalf("hash %d: GetN(%s, 10) = %v, Get = %s", hash, key, got, r.Get(key))
+			}
+		}
+	}
+}
+
+func TestRingConcurrent(t *testing.T) {
+	r := NewRing(50, RingHash64)
+	r.Add("a")
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 100; i++ {
+			r.Add("n" + strconv.Itoa(i))
+			r.Remove("n" + strconv.Itoa(i-1))
+		}
+	}()
+	for i := 0; i < 1000; i++ {
+		if r.Get(strconv.Itoa(i)) == "" {
+			t.Fatal("Get returned no member during updates")
+		}
+		r.GetN(strconv.Itoa(i), 2)
+	}
+	<-done
+}
@@ -0,0 +1,156 @@
+package consistent
+
+import (
+	"sort"
+	"strconv"
+	"sync"
+
+	"github.com/twmb/murmur3"
+)
+
+// RingHash selects the hash a Ring places members and keys with.
+type RingHash int
+
+const (
+	// RingHash32 hashes with StringSum32.
+	RingHash32 RingHash = iota
+	// RingHash64 hashes with StringSum64, which equals Sum64 over the
+	// key's bytes.
+	RingHash64
+)
+
+func (h RingHash) sum(s string) uint64 {
+	if h == RingHash64 {
+		return murmur3.StringSum64(s)
+	}
+	return uint64(murmur3.StringSum32(s))
+}
+
+// Ring is a consistent hash ring laid out as groupcache's consistenthash
+// package lays out its ring. Each member is placed on the ring at a number of
+// virtual points, the hashes of the member's name prefixed by the decimal
+// replica number ("0name", "1name", ...), and a key belongs to the member
+// owning the first point at or after the key's hash, wrapping around.
+//
+// A Ring does not interoperate with Ketama clients, which hash with MD5 and
+// place four points per digest.
+//
+// A Ring is safe for concurrent use.
+type Ring struct {
+	hash     RingHash
+	replicas int
+
+	mu      sync.RWMutex
+	points  []ringPoint // sorted by hash, then member
+	members map[string]struct{}
+}
+
+type ringPoint struct {
+	hash   uint64
+	member string
+}
+
+// NewRing returns an empty ring that places each member at replicas virtual
+// points, hashing with hash. Replicas is raised to at least one.
+func NewRing(replicas int, hash RingHash) *Ring {
+	if replicas < 1 {
+		replicas = 1
+	}
+	return &Ring{
+		hash:     hash,
+		replicas: replicas,
+		members:  make(map[string]struct{}),
+	}
+}
+
+// Add adds members to the ring, ignoring any already present.
+func (r *Ring) Add(members ...string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, m := range members {
+		if _, exists := r.members[m]; exists {
+			continue
+		}
+		r.members[m] = struct{}{}
+		for i := 0; i < r.replicas; i++ {
+			r.points = append(r.points, ringPoint{r.hash.sum(strconv.Itoa(i) + m), m})
+		}
+	}
+	sort.Slice(r.points, func(i, j int) bool {
+		if r.points[i].hash != r.points[j].hash {
+			return r.points[i].hash < r.points[j].hash
+		}
+		return r.points[i].member < r.points[j].member
+	})
+}
+
+// Remove removes members from the ring, ignoring any not present.
+func (r *Ring) Remove(members ...string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, m := range members {
+		delete(r.members, m)
+	}
+	kept := r.points[:0]
+	for _, p := range r.points {
+		if _, exists := r.members[p.member]; exists {
+			kept = append(kept, p)
+		}
+	}
+	r.points = kept
+}
+
+// Members returns the ring's members, sorted.
+func (r *Ring) Members() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	members := make([]string, 0, len(r.members))
+	for m := range r.members {
+		members = append(members, m)
+	}
+	sort.Strings(members)
+	return members
+}
+
+// search returns the index of the first point at or after h, wrapping.
+func (r *Ring) search(h uint64) int {
+	i := sort.Search(len(r.points), func(i int) bool { return r.points[i].hash >= h })
+	if i == len(r.points) {
+		i = 0
+	}
+	return i
+}
+
+// Get returns the member owning key, or the empty string if the ring is
+// empty.
+func (r *Ring) Get(key string) string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if len(r.points) == 0 {
+		return ""
+	}
+	return r.points[r.search(r.hash.sum(key))].member
+}
+
+// GetN returns up to n distinct members for key, found by walking the ring
+// from the key's position. The first member is always Get(key).
+func (r *Ring) GetN(key string, n int) []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if n > len(r.members) {
+		n = len(r.members)
+	}
+	if n <= 0 {
+		return nil
+	}
+	found := make([]string, 0, n)
+	seen := make(map[string]struct{}, n)
+	for i := r.search(r.hash.sum(key)); len(found) < n; i = (i + 1) % len(r.points) {
+		m := r.points[i].member
+		if _, dup := seen[m]; !dup {
+			seen[m] = struct{}{}
+			found = append(found, m)
+		}
+	}
+	return found
+}
@@ -0,0 +1,86 @@
+// Package elasticsearch computes the shard that Elasticsearch routes a
+// document to, so that clients can predict where a document will be indexed.
+//
+// Elasticsearch's Murmur3HashFunction hashes the UTF-16 code units of a
+// routing value, each as two little endian bytes, with murmur3 x86_32 and a
+// seed of zero. The shard is then chosen among the index's routing shards,
+// which may number a multiple of its real shards so that the index can later
+// be split.
+package elasticsearch
+
+import (
+	"unicode"
+	"unicode/utf16"
+
+	"github.com/twmb/murmur3"
+)
+
+// Hash returns Elasticsearch's Murmur3HashFunction.hash of routing.
+func Hash(routing string) int32 {
+	b := make([]byte, 0, 2*len(routing))
+	for _, r := range routing {
+		if r1, r2 := utf16.EncodeRune(r); r1 != unicode.ReplacementChar {
+			b = append(b, byte(r1), byte(r1>>8), byte(r2), byte(r2>>8))
+			continue
+		}
+		b = append(b, byte(r), byte(r>>8))
+	}
+	return int32(murmur3.SeedSum32(0, b))
+}
+
+// ShardID returns the shard of a document with the given id and routing.
+//
+// An empty routing means the document has none, as Elasticsearch treats an
+// empty routing parameter; the document is then routed by its id.
+// RoutingNumShards is the index's number_of_routing_shards, which must be a
+// multiple of numShards; if it is zero, numShards is used, matching indices
+// created before Elasticsearch 7. PartitionSize is the index's
+// routing_partition_size, where one or less means the index is not
+// partitioned. With a partition size above one, documents sharing a routing
+// spread over that many shards, chosen by their ids.
+//
+// Elasticsearch rejects documents without routing in a partitioned index, and
+// ShardID panics if routing is empty and partitionSize is above one.
+func ShardID(routing, id string, numShards, routingNumShards, partitionSize int) int {
+	if routingNumShards == 0 {
+		routingNumShards = numShards
+	}
+	var offset int32
+	switch {
+	case partitionSize > 1:
+		if routing == "" {
+			panic("elasticsearch: routing is required in a partitioned index")
+		}
+		offset = floorMod(Hash(id), int32(partitionSize))
+	case routing == "":
+		routing = id
+	}
+	// The addition wraps like Java's int arithmetic.
+	hash := Hash(routing) + offset
+	return int(floorMod(hash, int32(routingNumShards))) / (routingNumShards / numShards)
+}
+
+// DefaultRoutingNumShards returns the number_of_routing_shards that
+// Elasticsearch 7 and later give an index of numShards shards when it is not
+// set: numShards doubled as many times as keeps it at most 1024, and at least
+// once.
+func DefaultRoutingNumShards(numShards int) int {
+	log2NumShards := 0
+	for 1<<uint(log2NumShards) < numShards {
+		log2NumShards++
+	}
+	splits := 10 - log2NumShards
+	if splits < 1 {
+		splits = 1
+	}
+	return numShards << uint(splits)
+}
+
+// floorMod is Java's Math.floorMod.
+func floorMod(x, y int32) int32 {
+	m := x % y
+	if m != 0 && (m < 0) != (y < 0) {
+		m += y
+	}
+	return m
+}
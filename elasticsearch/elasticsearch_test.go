@@ -0,0 +1,162 @@
+package elasticsearch
+
+import (
+	"strconv"
+	"testing"
+	"testing/quick"
+	"unicode/utf16"
+
+	"github.com/twmb/murmur3"
+)
+
+func TestHash(t *testing.T) {
+	// From Elasticsearch's Murmur3HashFunctionTests.
+	for _, test := range []struct {
+		routing string
+		exp     uint32
+	}{
+		{"hell", 0x5a0cb7c3},
+		{"hello", 0xd7c31989},
+		{"hello w", 0x22ab2984},
+		{"hello wo", 0xdf0ca123},
+		{"hello wor", 0xe7744d61},
+		{"The quick brown fox jumps over the lazy dog", 0xe07db09c},
+		{"The quick brown fox jumps over the lazy cog", 0x4e63d2ad},
+	} {
+		if got := Hash(test.routing); got != int32(test.exp) {
+			t.Errorf("Hash(%q): got %08x != exp %08x", test.routing, uint32(got), test.exp)
+		}
+	}
+
+	// Supplementary characters hash as their surrogate pairs.
+	if err := quick.Check(func(s string) bool {
+		var b []byte
+		for _, c := range utf16.Encode([]rune(s)) {
+			b = append(b, byte(c), byte(c>>8))
+		}
+		return Hash(s) == int32(murmur3.SeedSum32(0, b))
+	}, nil); err != nil {
+		t.Error(err)
+	}
+	if Hash("😀") != int32(murmur3.SeedSum32(0, []byte{0x3d, 0xd8, 0x00, 0xde})) {
+		t.Error("Hash of a supplementary character does not use its surrogate pair")
+	}
+}
+
+func TestShardID(t *testing.T) {
+	// Without routing, the id routes; with it, the id is ignored.
+	for i := 0; i < 100; i++ {
+		id := strconv.Itoa(i)
+		if got, exp := ShardID("", id, 5, 0, 1), int(floorMod(Hash(id), 5)); got != exp {
+			t.Errorf("ShardID(%q): got %d != exp %d", id, got, exp)
+		}
+		if ShardID("user1", id, 5, 0, 1) != ShardID("user1", "other", 5, 0, 1) {
+			t.Errorf("ShardID with routing depends on id %q", id)
+		}
+	}
+
+	// The hash of "hello" is negative; floorMod keeps the shard positive.
+	if got := ShardID("hello", "", 7, 0, 1); got != int(floorMod(int32(-0x283ce677), 7)) || got < 0 {
+		t.Errorf("ShardID(hello) = %d", got)
+	}
+}
+
+func TestShardIDShrink(t *testing.T) {
+	// As in Elasticsearch's OperationRoutingTests, an index shrunk from
+	// routingNumShards shards routes each document to its old shard divided
+	// by the shrink factor.
+	for _, splits := range [][2]int{{8, 4}, {20, 10}, {36, 12}, {15, 5}} {
+		for i := 0; i < 100; i++ {
+			id := "doc" + strconv.Itoa(i)
+			orig := ShardID("", id, splits[0], 0, 1)
+			shrunk := ShardID("", id, splits[1], splits[0], 1)
+			if exp := orig / (splits[0] / splits[1]); shrunk != exp {
+				t.Fatalf("%v, %s: shrunk shard %d != %d", splits, id, shrunk, exp)
+			}
+		}
+	}
+}
+
+func TestShardIDPartitioned(t *testing.T) {
+	// Documents sharing a routing spread over exactly partitionSize
+	// consecutive routing shards.
+	const numShards, partitionSize = 20, 4
+	shards := make(map[int]bool)
+	for i := 0; i < 1000; i++ {
+		shards[ShardID("tenant", strconv.Itoa(i), numShards, 0, partitionSize)] = true
+	}
+	if len(shards) != partitionSize {
+		t.Fatalf("routing spread over %d shards, want %d", len(shards), partitionSize)
+	}
+	base := int(floorMod(Hash("tenant"), numShards))
+	for i := 0; i < partitionSize; i++ {
+		if !shards[(base+i)%numShards] {
+			t.Errorf("shard %d of the partition starting at %d unused", (base+i)%numShards, base)
+		}
+	}
+
+	// From Elasticsearch's OperationRoutingTests: testPartitionedIndexShrunk,
+	// an index of 4 shards and 8 routing shards with partitions of 3, and
+	// testPartitionedIndexBWC, an index of 6 shards with partitions of 2.
+	for _, test := range []struct {
+		shards, routingShards, partitionSize int
+		exp                                  map[string]map[string]int
+	}{
+		{4, 8, 3, map[string]map[string]int{
+			"a": {"a_0": 1, "a_1": 2, "a_2": 2, "a_3": 2, "a_4": 1, "a_5": 2},
+			"c": {"c_0": 1, "c_1": 1, "c_2": 0, "c_3": 0, "c_4": 0, "c_5": 1},
+		}},
+		{6, 0, 2, map[string]map[string]int{
+			"a": {"a_0": 3, "a_1": 2, "a_2": 2, "a_3": 3},
+			"b": {"b_0": 5, "b_1": 0, "b_2": 0, "b_3": 0},
+			"c": {"c_0": 4, "c_1": 4, "c_2": 3, "c_3": 4},
+			"d": {"d_0": 3, "d_1": 4, "d_2": 4, "d_3": 4},
+		}},
+	} {
+		for routing, ids := range test.exp {
+			for id, exp := range ids {
+				if got := ShardID(routing, id, test.shards, test.routingShards, test.partitionSize); got != exp {
+					t.Errorf("%d shards, partitions of %d: ShardID(%q, %q) = %d, want %d",
+						test.shards, test.partitionSize, routing, id, got, exp)
+				}
+			}
+		}
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("ShardID without routing in a partitioned index did not panic")
+		}
+	}()
+	ShardID("", "id", numShards, 0, partitionSize)
+}
+
+func TestDefaultRoutingNumShards(t *testing.T) {
+	for _, test := range []struct{ shards, exp int }{
+		{1, 1024},
+		{2, 1024},
+		{3, 768},
+		{5, 640},
+		{512, 1024},
+		{600, 1200},
+		{1024, 2048},
+	} {
+		if got := DefaultRoutingNumShards(test.shards); got != test.exp {
+			t.Errorf("DefaultRoutingNumShards(%d): got %d != exp %d", test.shards, got, test.exp)
+		}
+	}
+}
+
+func TestFloorMod(t *testing.T) {
+	for _, test := range []struct{ x, y, exp int32 }{
+		{7, 3, 1},
+		{-7, 3, 2},
+		{7, -3, -2},
+		{-7, -3, -1},
+		{-6, 3, 0},
+	} {
+		if got := floorMod(test.x, test.y); got != test.exp {
+			t.Errorf("floorMod(%d, %d): got %d != exp %d", test.x, test.y, got, test.exp)
+		}
+	}
+}
@@ -0,0 +1,223 @@
+// Package featurehash implements the hashing trick, mapping tokens to the
+// columns of a fixed width sparse vector, compatibly with scikit-learn's
+// HashingVectorizer and FeatureHasher.
+//
+// scikit-learn hashes the UTF-8 of each token with murmurhash3_32 and a seed
+// of zero, interpreted as a signed int32 (positive=False). The column is the
+// absolute value of that hash modulo the number of features, and with
+// alternate signs enabled, tokens hashing negative count as -1 rather than
+// 1, so that collisions tend to cancel rather than accumulate.
+package featurehash
+
+import (
+	"math"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/twmb/murmur3"
+)
+
+// SignedSum32 returns the murmur3 x86_32 hash of data as a signed int32, as
+// scikit-learn's murmurhash3_32(data, seed, positive=False).
+func SignedSum32(seed uint32, data string) int32 {
+	return int32(murmur3.SeedStringSum32(seed, data))
+}
+
+// Norm selects how a Vectorizer normalizes its vectors.
+type Norm int
+
+const (
+	// NormL2 scales vectors to unit Euclidean length, scikit-learn's
+	// default.
+	NormL2 Norm = iota
+	// NormL1 scales vectors so that their absolute values sum to one.
+	NormL1
+	// NormNone leaves vectors unscaled.
+	NormNone
+)
+
+// Vector is a sparse vector: Values[i] is the value of column Indices[i].
+// Indices are sorted and unique.
+type Vector struct {
+	Indices []int32
+	Values  []float64
+}
+
+// Vectorizer converts documents to hashed sparse vectors. Its fields mirror
+// the parameters of scikit-learn's HashingVectorizer of the same names; New
+// returns a Vectorizer with scikit-learn's defaults.
+//
+// A document is preprocessed, lowercased, tokenized and expanded to n-grams
+// by Analyze, then hashed by HashTokens.
+type Vectorizer struct {
+	// NFeatures is the number of columns, which must be positive.
+	NFeatures int
+	// AlternateSign negates the contribution of tokens whose hash is
+	// negative.
+	AlternateSign bool
+	// Binary sets every non-empty column to one before normalizing.
+	Binary bool
+	// Norm selects the normalization of each vector.
+	Norm Norm
+
+	// Preprocessor, if non-nil, is applied to each document first. As in
+	// scikit-learn, it replaces lowercasing, so Lowercase is ignored.
+	Preprocessor func(string) string
+	// Lowercase lowercases each document when there is no Preprocessor.
+	Lowercase bool
+	// Tokenizer splits a document into tokens. If nil, DefaultTokenizer
+	// is used.
+	Tokenizer func(string) []string
+	// NGramMin and NGramMax bound the lengths of the word n-grams
+	// generated from the tokens, which are joined by single spaces.
+	NGramMin, NGramMax int
+}
+
+// New returns a Vectorizer of nFeatures columns with scikit-learn's
+// defaults: alternating signs, L2 normalization, lowercasing, the default
+// tokenizer, and unigrams only.
+func New(nFeatures int) *Vectorizer {
+	return &Vectorizer{
+		NFeatures:     nFeatures,
+		AlternateSign: true,
+		Norm:          NormL2,
+		Lowercase:     true,
+		NGramMin:      1,
+		NGramMax:      1,
+	}
+}
+
+// DefaultTokenizer returns the tokens of doc matched by scikit-learn's
+// default token_pattern, `(?u)\b\w\w+\b`: runs of at least two letters,
+// numbers or underscores.
+func DefaultTokenizer(doc string) []string {
+	var tokens []string
+	start := -1
+	for i, r := range doc {
+		if r == '_' || unicode.IsLetter(r) || unicode.IsNumber(r) {
+			if start < 0 {
+				start = i
+			}
+			continue
+		}
+		if start >= 0 && utf8.RuneCountInString(doc[start:i]) >= 2 {
+			tokens = append(tokens, doc[start:i])
+		}
+		start = -1
+	}
+	if start >= 0 && utf8.RuneCountInString(doc[start:]) >= 2 {
+		tokens = append(tokens, doc[start:])
+	}
+	return tokens
+}
+
+// Analyze returns the tokens and n-grams of doc that Transform hashes.
+func (v *Vectorizer) Analyze(doc string) []string {
+	switch {
+	case v.Preprocessor != nil:
+		doc = v.Preprocessor(doc)
+	case v.Lowercase:
+		doc = strings.ToLower(doc)
+	}
+	tokenize := v.Tokenizer
+	if tokenize == nil {
+		tokenize = DefaultTokenizer
+	}
+	tokens := tokenize(doc)
+
+	lo, hi := v.NGramMin, v.NGramMax
+	if lo < 1 {
+		lo = 1
+	}
+	if hi <= 1 {
+		return tokens
+	}
+	var grams []string
+	if lo == 1 {
+		grams = append(grams, tokens...)
+		lo = 2
+	}
+	for n := lo; n <= hi && n <= len(tokens); n++ {
+		for i := 0; i+n <= len(tokens); i++ {
+			grams = append(grams, strings.Join(tokens[i:i+n], " "))
+		}
+	}
+	return grams
+}
+
+// Transform returns the hashed vector of doc, as HashingVectorizer.transform.
+func (v *Vectorizer) Transform(doc string) Vector {
+	return v.HashTokens(v.Analyze(doc))
+}
+
+// HashTokens returns the hashed vector of already analyzed tokens.
+//
+// As in scikit-learn, a column whose contributions cancel to zero is still
+// present in the vector, and Binary sets it to one.
+func (v *Vectorizer) HashTokens(tokens []string) Vector {
+	sums := make(map[int32]float64, len(tokens))
+	for _, tok := range tokens {
+		col, sign := v.column(tok)
+		sums[col] += sign
+	}
+
+	vec := Vector{
+		Indices: make([]int32, 0, len(sums)),
+		Values:  make([]float64, len(sums)),
+	}
+	for col := range sums {
+		vec.Indices = append(vec.Indices, col)
+	}
+	sort.Slice(vec.Indices, func(i, j int) bool { return vec.Indices[i] < vec.Indices[j] })
+	for i, col := range vec.Indices {
+		vec.Values[i] = sums[col]
+		if v.Binary {
+			vec.Values[i] = 1
+		}
+	}
+
+	var norm float64
+	switch v.Norm {
+	case NormL2:
+		for _, x := range vec.Values {
+			norm += x * x
+		}
+		norm = math.Sqrt(norm)
+	case NormL1:
+		for _, x := range vec.Values {
+			norm += math.Abs(x)
+		}
+	}
+	if norm != 0 {
+		for i := range vec.Values {
+			vec.Values[i] /= norm
+		}
+	}
+	return vec
+}
+
+// column returns the column of tok and the sign of its contribution.
+func (v *Vectorizer) column(tok string) (int32, float64) {
+	h := SignedSum32(0, tok)
+	sign := 1.0
+	if v.AlternateSign && h < 0 {
+		sign = -1
+	}
+	return index(h, int64(v.NFeatures)), sign
+}
+
+// index returns |h| mod n.
+func index(h int32, n int64) int32 {
+	if h == math.MinInt32 {
+		// scikit-learn avoids abs(-2^31) by computing this equivalent
+		// of 2^31 mod n.
+		return int32((math.MaxInt32 - (n - 1)) % n)
+	}
+	col := int64(h)
+	if col < 0 {
+		col = -col
+	}
+	return int32(col % n)
+}
@@ -0,0 +1,122 @@
+package featurehash
+
+import (
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestSignedSum32(t *testing.T) {
+	// From scikit-learn's tests of murmurhash3_32.
+	for _, test := range []struct {
+		seed uint32
+		data string
+		exp  int32
+		pos  uint32 // positive=True
+	}{
+		{0, "foo", -156908512, 4138058784},
+		{42, "foo", -1322301282, 2972666014},
+	} {
+		if got := SignedSum32(test.seed, test.data); got != test.exp {
+			t.Errorf("SignedSum32(%d, %q): got %d != exp %d", test.seed, test.data, got, test.exp)
+		}
+		if got := uint32(SignedSum32(test.seed, test.data)); got != test.pos {
+			t.Errorf("uint32(SignedSum32(%d, %q)): got %d != exp %d", test.seed, test.data, got, test.pos)
+		}
+	}
+}
+
+func TestColumn(t *testing.T) {
+	v := New(1 << 20)
+	for _, test := range []struct {
+		tok  string
+		col  int32
+		sign float64
+	}{
+		{"foo", 156908512 % (1 << 20), -1},
+		{"bar", 1158584717 % (1 << 20), 1},
+		{"über", 1610176724 % (1 << 20), -1},
+	} {
+		col, sign := v.column(test.tok)
+		if col != test.col || sign != test.sign {
+			t.Errorf("column(%q): got (%d, %v) != exp (%d, %v)", test.tok, col, sign, test.col, test.sign)
+		}
+	}
+
+	// The one hash without an int32 absolute value: 2^31 mod 1000.
+	if got := index(math.MinInt32, 1000); got != 648 {
+		t.Errorf("index(MinInt32, 1000): got %d != exp 648", got)
+	}
+}
+
+func TestTransform(t *testing.T) {
+	// With 16 features: "the" hashes to -1132748958, column 14, sign -1;
+	// "quick" to 771291085, column 13, sign 1. "brown", "fox" and "dog"
+	// land alone in columns 0, 7 and 5 with signs 1, -1 and -1.
+	doc := "The quick brown fox; the QUICK dog."
+	v := New(16)
+	if got, exp := v.Analyze(doc), strings.Fields("the quick brown fox the quick dog"); !reflect.DeepEqual(got, exp) {
+		t.Fatalf("Analyze: got %q != exp %q", got, exp)
+	}
+
+	norm := math.Sqrt(11)
+	exp := Vector{
+		Indices: []int32{0, 5, 7, 13, 14},
+		Values:  []float64{1 / norm, -1 / norm, -1 / norm, 2 / norm, -2 / norm},
+	}
+	if got := v.Transform(doc); !reflect.DeepEqual(got, exp) {
+		t.Errorf("Transform: got %+v != exp %+v", got, exp)
+	}
+
+	v.Norm, v.AlternateSign = NormNone, false
+	exp.Values = []float64{1, 1, 1, 2, 2}
+	if got := v.Transform(doc); !reflect.DeepEqual(got, exp) {
+		t.Errorf("unsigned, unnormalized: got %+v != exp %+v", got, exp)
+	}
+
+	v.Norm, v.Binary = NormL1, true
+	exp.Values = []float64{0.2, 0.2, 0.2, 0.2, 0.2}
+	if got := v.Transform(doc); !reflect.DeepEqual(got, exp) {
+		t.Errorf("binary, l1: got %+v != exp %+v", got, exp)
+	}
+}
+
+func TestCancellation(t *testing.T) {
+	// With a single feature, "the" (sign -1) and "quick" (sign 1) collide
+	// and cancel.
+	v := New(1)
+	v.Norm = NormNone
+	got := v.HashTokens([]string{"the", "quick"})
+	if !reflect.DeepEqual(got, Vector{Indices: []int32{0}, Values: []float64{0}}) {
+		t.Errorf("cancelled column: got %+v", got)
+	}
+	v.Binary = true
+	if got := v.HashTokens([]string{"the", "quick"}); got.Values[0] != 1 {
+		t.Errorf("binary cancelled column: got %+v", got)
+	}
+}
+
+func TestTokenizerHooks(t *testing.T) {
+	if got, exp := DefaultTokenizer("a bc, déjà_vu 42 x1 é"), []string{"bc", "déjà_vu", "42", "x1"}; !reflect.DeepEqual(got, exp) {
+		t.Errorf("DefaultTokenizer: got %q != exp %q", got, exp)
+	}
+
+	v := New(16)
+	v.Preprocessor = func(s string) string { return strings.Replace(s, "-", " ", -1) }
+	v.Tokenizer = strings.Fields
+	v.NGramMax = 3
+	// The preprocessor replaces lowercasing, so "A" keeps its case.
+	exp := []string{"A", "b", "c", "A b", "b c", "A b c"}
+	if got := v.Analyze("A-b c"); !reflect.DeepEqual(got, exp) {
+		t.Errorf("Analyze with hooks: got %q != exp %q", got, exp)
+	}
+	v.NGramMin = 2
+	if got := v.Analyze("A-b c"); !reflect.DeepEqual(got, exp[3:]) {
+		t.Errorf("Analyze bigrams up: got %q != exp %q", got, exp[3:])
+	}
+	v.Preprocessor = nil
+	if got, exp := v.Analyze("A b-c"), []string{"a b-c"}; !reflect.DeepEqual(got, exp) {
+		t.Errorf("Analyze lowercased: got %q != exp %q", got, exp)
+	}
+}
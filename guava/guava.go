@@ -0,0 +1,280 @@
+// Package guava reproduces the murmur3 hash functions of Google's Guava
+// library (com.google.common.hash.Hashing), byte for byte.
+//
+// Guava differs from the canonical murmur3 in a few observable ways that this
+// package takes care of:
+//
+//   - Seeds are Java ints. murmur3_128 sign extends its seed into both 64 bit
+//     lanes, so negative seeds do not match SeedSum128(uint64(seed), ...).
+//   - Primitive values are hashed as their little endian encoding: putInt as
+//     four bytes, putLong as eight, putChar as two and so on.
+//   - HashCode.asBytes is little endian, h1 then h2, whereas Hash128.Sum in
+//     the parent package is big endian.
+//   - The original murmur3_32 mishandles characters outside of the Basic
+//     Multilingual Plane in HashFunction.hashString; Murmur3_32 reproduces
+//     that bug, while Murmur3_32Fixed matches Guava's murmur3_32_fixed.
+//
+// Strings are Go strings and thus UTF-8 already. PutString and HashString
+// hash that UTF-8 directly, which matches Guava's putString and hashString
+// with UTF_8 for any string Java can represent. The UnencodedChars functions
+// hash the UTF-16 code units of the string, as Java would.
+package guava
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"hash"
+	"math"
+	"math/bits"
+	"unicode/utf16"
+	"unicode/utf8"
+
+	"github.com/twmb/murmur3"
+)
+
+// HashCode is the result of a HashFunction or Hasher, laid out as Guava's
+// HashCode.asBytes.
+type HashCode []byte
+
+// Bits returns the number of bits in the hash code.
+func (h HashCode) Bits() int { return len(h) * 8 }
+
+// AsInt returns the first four bytes of the hash code as a little endian
+// int32, as Guava's HashCode.asInt.
+func (h HashCode) AsInt() int32 { return int32(binary.LittleEndian.Uint32(h)) }
+
+// AsLong returns the first eight bytes of the hash code as a little endian
+// int64, as Guava's HashCode.asLong. Like Guava, this panics if the hash code
+// has fewer than 64 bits.
+func (h HashCode) AsLong() int64 {
+	if len(h) < 8 {
+		panic("guava: AsLong requires a HashCode of at least 64 bits")
+	}
+	return int64(binary.LittleEndian.Uint64(h))
+}
+
+// PadToLong returns AsLong if the hash code has at least 64 bits, and
+// otherwise the zero extended AsInt, as Guava's HashCode.padToLong.
+func (h HashCode) PadToLong() int64 {
+	if len(h) < 8 {
+		return int64(uint32(h.AsInt()))
+	}
+	return h.AsLong()
+}
+
+// String returns the lowercase hex of the hash code's bytes, as Guava's
+// HashCode.toString.
+func (h HashCode) String() string { return hex.EncodeToString(h) }
+
+// HashFunction mirrors one of Guava's murmur3 HashFunctions.
+type HashFunction struct {
+	bits  int
+	seed  int32
+	fixed bool // murmur3_32_fixed rather than the legacy murmur3_32
+}
+
+// Murmur3_128 returns the equivalent of Guava's Hashing.murmur3_128(seed).
+func Murmur3_128(seed int32) HashFunction { return HashFunction{bits: 128, seed: seed} }
+
+// Murmur3_32 returns the equivalent of Guava's legacy Hashing.murmur3_32(seed),
+// including its bug in HashString for supplementary characters.
+func Murmur3_32(seed int32) HashFunction { return HashFunction{bits: 32, seed: seed} }
+
+// Murmur3_32Fixed returns the equivalent of Guava's
+// Hashing.murmur3_32_fixed(seed).
+func Murmur3_32Fixed(seed int32) HashFunction {
+	return HashFunction{bits: 32, seed: seed, fixed: true}
+}
+
+// Bits returns the number of bits in each hash code this function produces.
+func (f HashFunction) Bits() int { return f.bits }
+
+// NewHasher returns a Hasher for streaming values into the function.
+func (f HashFunction) NewHasher() *Hasher {
+	var h hash.Hash
+	if f.bits == 32 {
+		h = murmur3.SeedNew32(uint32(f.seed))
+	} else {
+		seed := uint64(int64(f.seed)) // Java's int to long widening.
+		h = murmur3.SeedNew128(seed, seed)
+	}
+	return &Hasher{h: h}
+}
+
+// HashBytes hashes b.
+func (f HashFunction) HashBytes(b []byte) HashCode {
+	return f.NewHasher().PutBytes(b).Hash()
+}
+
+// HashInt hashes the little endian encoding of v.
+func (f HashFunction) HashInt(v int32) HashCode {
+	return f.NewHasher().PutInt(v).Hash()
+}
+
+// HashLong hashes the little endian encoding of v.
+func (f HashFunction) HashLong(v int64) HashCode {
+	return f.NewHasher().PutLong(v).Hash()
+}
+
+// HashUnencodedChars hashes the UTF-16 code units of s, little endian.
+func (f HashFunction) HashUnencodedChars(s string) HashCode {
+	return f.NewHasher().PutUnencodedChars(s).Hash()
+}
+
+// HashString hashes the UTF-8 of s, as Guava's hashString with UTF_8.
+//
+// For Murmur3_32, every valid four byte UTF-8 sequence overlaps the bytes
+// that follow it, exactly as Guava's legacy murmur3_32 does. All other
+// functions hash s unchanged.
+func (f HashFunction) HashString(s string) HashCode {
+	if f.bits != 32 || f.fixed {
+		return f.NewHasher().PutString(s).Hash()
+	}
+	return legacyHashString32(uint32(f.seed), s)
+}
+
+// legacyHashString32 reproduces Murmur3_32HashFunction.hashString from
+// Guava's legacy murmur3_32, which forgets to advance its buffer past four
+// byte sequences, leaving the next bytes to be or'd on top of them. It is
+// written from the source of Guava 30.1.1; no output of Guava itself for
+// strings beyond the BMP is recorded to check it against.
+func legacyHashString32(seed uint32, s string) HashCode {
+	var (
+		h1     = seed
+		buffer uint64
+		shift  uint
+		length int
+	)
+	for i := 0; i < len(s); {
+		r, size := utf8.DecodeRuneInString(s[i:])
+		if size == 4 && r != utf8.RuneError {
+			k := uint32(s[i]) | uint32(s[i+1])<<8 | uint32(s[i+2])<<16 | uint32(s[i+3])<<24
+			buffer |= uint64(k) << shift
+		} else {
+			for j := 0; j < size; j++ {
+				buffer |= uint64(s[i+j]) << shift
+				shift += 8
+			}
+		}
+		i += size
+		length += size
+
+		if shift >= 32 {
+			h1 = murmur3.Mix32Block(h1, uint32(buffer))
+			buffer >>= 32
+			shift -= 32
+		}
+	}
+	h1 ^= mixK1(uint32(buffer))
+	h1 = murmur3.Fmix32(h1 ^ uint32(length))
+
+	var code [4]byte
+	binary.LittleEndian.PutUint32(code[:], h1)
+	return code[:]
+}
+
+// mixK1 is the step of murmur3_32, as named in Guava, that mixes the final,
+// partial block before it is folded into the hash.
+func mixK1(k1 uint32) uint32 {
+	k1 *= 0xcc9e2d51
+	k1 = bits.RotateLeft32(k1, 15)
+	k1 *= 0x1b873593
+	return k1
+}
+
+// Hasher mirrors Guava's Hasher for the murmur3 functions. Every Put method
+// returns the Hasher so that calls can be chained.
+type Hasher struct {
+	h       hash.Hash
+	scratch [8]byte
+}
+
+// PutByte hashes b.
+func (h *Hasher) PutByte(b byte) *Hasher {
+	h.scratch[0] = b
+	h.h.Write(h.scratch[:1])
+	return h
+}
+
+// PutBytes hashes b.
+func (h *Hasher) PutBytes(b []byte) *Hasher {
+	h.h.Write(b)
+	return h
+}
+
+// PutBool hashes a single byte, 1 for true and 0 for false.
+func (h *Hasher) PutBool(b bool) *Hasher {
+	if b {
+		return h.PutByte(1)
+	}
+	return h.PutByte(0)
+}
+
+// PutShort hashes the little endian encoding of v.
+func (h *Hasher) PutShort(v int16) *Hasher {
+	binary.LittleEndian.PutUint16(h.scratch[:], uint16(v))
+	h.h.Write(h.scratch[:2])
+	return h
+}
+
+// PutChar hashes the little endian encoding of the UTF-16 code unit c.
+func (h *Hasher) PutChar(c uint16) *Hasher {
+	return h.PutShort(int16(c))
+}
+
+// PutInt hashes the little endian encoding of v.
+func (h *Hasher) PutInt(v int32) *Hasher {
+	binary.LittleEndian.PutUint32(h.scratch[:], uint32(v))
+	h.h.Write(h.scratch[:4])
+	return h
+}
+
+// PutLong hashes the little endian encoding of v.
+func (h *Hasher) PutLong(v int64) *Hasher {
+	binary.LittleEndian.PutUint64(h.scratch[:], uint64(v))
+	h.h.Write(h.scratch[:8])
+	return h
+}
+
+// PutFloat hashes the raw bits of v, as Java's Float.floatToRawIntBits.
+func (h *Hasher) PutFloat(v float32) *Hasher {
+	return h.PutInt(int32(math.Float32bits(v)))
+}
+
+// PutDouble hashes the raw bits of v, as Java's Double.doubleToRawLongBits.
+func (h *Hasher) PutDouble(v float64) *Hasher {
+	return h.PutLong(int64(math.Float64bits(v)))
+}
+
+// PutString hashes the UTF-8 of s, as Guava's putString with UTF_8.
+func (h *Hasher) PutString(s string) *Hasher {
+	h.h.Write([]byte(s))
+	return h
+}
+
+// PutUnencodedChars hashes the UTF-16 code units of s, little endian.
+func (h *Hasher) PutUnencodedChars(s string) *Hasher {
+	for _, c := range utf16.Encode([]rune(s)) {
+		h.PutChar(c)
+	}
+	return h
+}
+
+// Hash returns the hash code of everything put so far.
+//
+// Unlike Guava, the Hasher remains usable after Hash; further puts continue
+// from where the previous hash left off.
+func (h *Hasher) Hash() HashCode {
+	switch d := h.h.(type) {
+	case murmur3.Hash128:
+		h1, h2 := d.Sum128()
+		code := make([]byte, 16)
+		binary.LittleEndian.PutUint64(code, h1)
+		binary.LittleEndian.PutUint64(code[8:], h2)
+		return code
+	default:
+		code := make([]byte, 4)
+		binary.LittleEndian.PutUint32(code, h.h.(hash.Hash32).Sum32())
+		return code
+	}
+}
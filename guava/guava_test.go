@@ -0,0 +1,164 @@
+package guava
+
+import (
+	"encoding/binary"
+	"testing"
+)
+
+// Unless noted, golden values below are from Guava's Murmur3Hash32Test and
+// Murmur3Hash128Test.
+
+func TestMurmur3_32Known(t *testing.T) {
+	for _, test := range []struct {
+		in  int32
+		exp int32
+	}{
+		{0, 593689054},
+		{-42, -189366624},
+		{42, -1134849565},
+		{-1 << 31, -1718298732},
+		{1<<31 - 1, -1653689534},
+	} {
+		if got := Murmur3_32(0).HashInt(test.in).AsInt(); got != test.exp {
+			t.Errorf("HashInt(%d): got %d != exp %d", test.in, got, test.exp)
+		}
+	}
+
+	for _, test := range []struct {
+		in  int64
+		exp int32
+	}{
+		{0, 1669671676},
+		{-42, -846261623},
+		{42, 1871679806},
+		{-1 << 63, 1366273829},
+		{1<<63 - 1, -2106506049},
+	} {
+		if got := Murmur3_32(0).HashLong(test.in).AsInt(); got != test.exp {
+			t.Errorf("HashLong(%d): got %d != exp %d", test.in, got, test.exp)
+		}
+	}
+
+	for _, test := range []struct {
+		in  string
+		exp int32
+	}{
+		{"", 0},
+		{"k", 679745764},
+		{"hell", 1510782915},
+		{"hello", -675079799},
+		{"http://www.google.com/", 1935035788},
+		{"The quick brown fox jumps over the lazy dog", -528633700},
+	} {
+		if got := Murmur3_32(0).HashUnencodedChars(test.in).AsInt(); got != test.exp {
+			t.Errorf("HashUnencodedChars(%q): got %d != exp %d", test.in, got, test.exp)
+		}
+	}
+}
+
+func TestMurmur3_32String(t *testing.T) {
+	// From Guava's Murmur3_32HashFunctionTest, which checks the legacy
+	// murmur3_32 against the same values for strings within the BMP only.
+	for _, test := range []struct {
+		in  string
+		exp uint32
+	}{
+		{"", 0},
+		{"k", 0xcfbda5d1},
+		{"hell", 0xa167dbf3},
+		{"hello", 0x248bfa47},
+		{"http://www.google.com/", 0x3d41b97c},
+		{"The quick brown fox jumps over the lazy dog", 0x2e4ff723},
+		{"ABCDefGHIޙ", 0xb5a4be05},
+		{"毎月１日,毎週月曜日", 0xfc5ba834},
+		{"surrogate pair: \U0001F4B0", 0x8a5c3699},
+	} {
+		fixed := Murmur3_32Fixed(0).HashString(test.in)
+		if got := uint32(fixed.AsInt()); got != test.exp {
+			t.Errorf("fixed HashString(%q): got %#x != exp %#x", test.in, got, test.exp)
+		}
+		streamed := Murmur3_32Fixed(0).NewHasher().PutString(test.in).Hash()
+		if got := uint32(streamed.AsInt()); got != test.exp {
+			t.Errorf("fixed PutString(%q): got %#x != exp %#x", test.in, got, test.exp)
+		}
+		// No legacy output from Guava is recorded for strings beyond
+		// the BMP; there, the legacy hash must merely differ.
+		bmp := true
+		for _, r := range test.in {
+			bmp = bmp && r <= 0xffff
+		}
+		legacy := uint32(Murmur3_32(0).HashString(test.in).AsInt())
+		if bmp != (legacy == test.exp) {
+			t.Errorf("legacy HashString(%q): got %#x, fixed %#x", test.in, legacy, test.exp)
+		}
+	}
+}
+
+func TestMurmur3_128Known(t *testing.T) {
+	for _, test := range []struct {
+		seed int32
+		exp1 uint64
+		exp2 uint64
+		in   string
+	}{
+		{0, 0x629942693e10f867, 0x92db0b82baeb5347, "hell"},
+		{1, 0xa78ddff5adae8d10, 0x128900ef20900135, "hello"},
+		{2, 0x8a486b23f422e826, 0xf962a2c58947765f, "hello "},
+		{3, 0x2ea59f466f6bed8c, 0xc610990acc428a17, "hello w"},
+		{4, 0x79f6305a386c572c, 0x46305aed3483b94e, "hello wo"},
+		{5, 0xc2219d213ec1f1b5, 0xa1d8e2e0a52785bd, "hello wor"},
+		{0, 0xe34bbc7bbc071b6c, 0x7a433ca9c49a9347, "The quick brown fox jumps over the lazy dog"},
+		{0, 0x658ca970ff85269a, 0x43fee3eaa68e5c3e, "The quick brown fox jumps over the lazy cog"},
+	} {
+		var exp [16]byte
+		binary.LittleEndian.PutUint64(exp[:], test.exp1)
+		binary.LittleEndian.PutUint64(exp[8:], test.exp2)
+
+		got := Murmur3_128(test.seed).HashBytes([]byte(test.in))
+		if string(got) != string(exp[:]) {
+			t.Errorf("HashBytes(%d, %q): got %s != exp %x", test.seed, test.in, got, exp)
+		}
+		got = Murmur3_128(test.seed).NewHasher().PutString(test.in).Hash()
+		if string(got) != string(exp[:]) {
+			t.Errorf("PutString(%d, %q): got %s != exp %x", test.seed, test.in, got, exp)
+		}
+		if got.AsLong() != int64(test.exp1) {
+			t.Errorf("AsLong(%d, %q): got %x != exp %x", test.seed, test.in, got.AsLong(), test.exp1)
+		}
+	}
+
+	// Known output from Python smhasher, quoted by Guava.
+	fox := Murmur3_128(0).HashString("The quick brown fox jumps over the lazy dog")
+	if got, exp := fox.String(), "6c1b07bc7bbc4be347939ac4a93c437a"; got != exp {
+		t.Errorf("fox String: got %s != exp %s", got, exp)
+	}
+}
+
+func TestHasherPrimitives(t *testing.T) {
+	// Chained puts must hash the same bytes as their encodings written out.
+	b := []byte{
+		7,
+		1,
+		0x34, 0x12,
+		0x78, 0x56, 0x34, 0x12,
+		0xef, 0xcd, 0xab, 0x89, 0x67, 0x45, 0x23, 0x01,
+		0x00, 0x00, 0x80, 0x3f,
+		0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0xf0, 0x3f,
+		'h', 0, 'i', 0,
+	}
+	for _, f := range []HashFunction{Murmur3_32Fixed(-7), Murmur3_128(-7)} {
+		got := f.NewHasher().
+			PutByte(7).
+			PutBool(true).
+			PutShort(0x1234).
+			PutInt(0x12345678).
+			PutLong(0x0123456789abcdef).
+			PutFloat(1).
+			PutDouble(1).
+			PutUnencodedChars("hi").
+			Hash()
+		if exp := f.HashBytes(b); got.String() != exp.String() {
+			t.Errorf("%d bits: got %s != exp %s", f.Bits(), got, exp)
+		}
+	}
+}
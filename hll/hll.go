@@ -0,0 +1,425 @@
+// Package hll provides a HyperLogLog++ cardinality estimator keyed on
+// murmur3's 64 bit hash.
+//
+// A Sketch starts out sparse, remembering the hashes it has seen at a
+// precision of 25 bits, which keeps small cardinalities nearly exact and
+// small sketches small. Once the sparse list would take more memory than the
+// dense registers, the sketch converts to the dense representation of 2^p one
+// byte registers for its precision p.
+//
+// Dense sketches are estimated as in HyperLogLog++ (Heule, Nunkesser and
+// Hall, "HyperLogLog in Practice", 2013): the raw HyperLogLog estimate is
+// corrected with the paper's empirical bias tables while it is at most five
+// times the number of registers, and linear counting is used instead while
+// its result is below the paper's threshold for the precision. Estimates
+// therefore match other HyperLogLog++ implementations given the same
+// registers.
+package hll
+
+import (
+	"encoding"
+	"encoding/binary"
+	"errors"
+	"math"
+	"math/bits"
+	"sort"
+
+	"github.com/twmb/murmur3"
+)
+
+// Make sure interfaces are correctly implemented.
+var (
+	_ encoding.BinaryMarshaler   = new(Sketch)
+	_ encoding.BinaryUnmarshaler = new(Sketch)
+)
+
+// MinPrecision and MaxPrecision bound the precision of a Sketch.
+const (
+	MinPrecision = 4
+	MaxPrecision = 18
+)
+
+const (
+	// sparsePrecision is p' in HyperLogLog++: the number of index bits
+	// kept by the sparse representation.
+	sparsePrecision = 25
+	// rhoBits is the width of the rho stored below the index in a sparse
+	// entry; rho at p' is at most maxSparseRho.
+	rhoBits      = 6
+	maxSparseRho = 64 - sparsePrecision + 1
+
+	magic = "mm3h\x01"
+
+	reprSparse = 0
+	reprDense  = 1
+)
+
+var (
+	errPrecision = errors.New("hll: precision out of range")
+	errMerge     = errors.New("hll: sketches have different precisions")
+	errState     = errors.New("hll: invalid sketch encoding")
+)
+
+// Sketch is a HyperLogLog++ sketch. The zero value is not usable; create
+// sketches with New.
+//
+// A Sketch is not safe for concurrent use.
+type Sketch struct {
+	p uint8
+
+	// While sparse, list holds sorted entries of a sparse index and the
+	// largest rho seen for it, one entry per index, and tmp holds unsorted
+	// entries yet to be merged into list. Once dense, both are nil and regs
+	// holds the 2^p registers.
+	list []uint32
+	tmp  []uint32
+	regs []uint8
+}
+
+// New returns an empty sketch with 2^precision registers. The relative
+// standard error of its estimates is about 1.04/sqrt(2^precision).
+func New(precision uint8) (*Sketch, error) {
+	if precision < MinPrecision || precision > MaxPrecision {
+		return nil, errPrecision
+	}
+	return &Sketch{p: precision}, nil
+}
+
+// Precision returns the precision of the sketch.
+func (s *Sketch) Precision() uint8 { return s.p }
+
+func (s *Sketch) m() int { return 1 << s.p }
+
+// Add adds data to the sketch.
+func (s *Sketch) Add(data []byte) { s.AddHash(murmur3.Sum64(data)) }
+
+// AddString is the string version of Add.
+func (s *Sketch) AddString(data string) { s.AddHash(murmur3.StringSum64(data)) }
+
+// AddHash adds an element that has already been hashed to 64 bits. Sketches
+// that are to be merged must hash their elements the same way.
+func (s *Sketch) AddHash(h uint64) {
+	if s.regs != nil {
+		idx, rho := denseEntry(h, s.p)
+		if rho > s.regs[idx] {
+			s.regs[idx] = rho
+		}
+		return
+	}
+	s.tmp = append(s.tmp, sparseEntry(h))
+	if len(s.tmp) >= s.tmpLimit() {
+		s.flush()
+	}
+}
+
+// denseEntry returns the register index and rho of h at precision p: the top
+// p bits and one more than the number of leading zeros in the rest.
+func denseEntry(h uint64, p uint8) (uint32, uint8) {
+	idx := uint32(h >> (64 - p))
+	rho := uint8(bits.LeadingZeros64(h<<p|1<<(p-1))) + 1
+	return idx, rho
+}
+
+// sparseEntry encodes the index and rho of h at the sparse precision.
+func sparseEntry(h uint64) uint32 {
+	idx, rho := denseEntry(h, sparsePrecision)
+	return idx<<rhoBits | uint32(rho)
+}
+
+// sparseToDense converts a sparse entry to its register index and rho at
+// precision p. The index bits dropped from the sparse index sit directly
+// above the bits rho was counted over, so rho grows by their width if they are
+// all zero.
+func sparseToDense(e uint32, p uint8) (uint32, uint8) {
+	sidx := e >> rhoBits
+	rho := uint8(e & (1<<rhoBits - 1))
+	shift := sparsePrecision - p
+	idx := sidx >> shift
+	if low := sidx & (1<<shift - 1); low != 0 {
+		return idx, uint8(bits.LeadingZeros32(low<<(32-shift))) + 1
+	}
+	return idx, rho + shift
+}
+
+func (s *Sketch) tmpLimit() int {
+	if l := s.m() / 16; l > 4 {
+		return l
+	}
+	return 4
+}
+
+// flush merges tmp into list, converting to dense if the list has grown
+// larger than the registers would be.
+func (s *Sketch) flush() {
+	if len(s.tmp) == 0 {
+		return
+	}
+	sort.Slice(s.tmp, func(i, j int) bool { return s.tmp[i] < s.tmp[j] })
+	s.list = mergeSparse(s.list, s.tmp)
+	s.tmp = s.tmp[:0]
+	if 4*len(s.list) > s.m() {
+		s.toDense()
+	}
+}
+
+// mergeSparse merges two sorted entry lists into a new list, keeping only the
+// largest rho for each index.
+func mergeSparse(a, b []uint32) []uint32 {
+	merged := make([]uint32, 0, len(a)+len(b))
+	add := func(e uint32) {
+		if n := len(merged); n > 0 && merged[n-1]>>rhoBits == e>>rhoBits {
+			if e > merged[n-1] {
+				merged[n-1] = e
+			}
+			return
+		}
+		merged = append(merged, e)
+	}
+	for len(a) > 0 && len(b) > 0 {
+		if a[0] < b[0] {
+			add(a[0])
+			a = a[1:]
+		} else {
+			add(b[0])
+			b = b[1:]
+		}
+	}
+	for _, e := range a {
+		add(e)
+	}
+	for _, e := range b {
+		add(e)
+	}
+	return merged
+}
+
+func (s *Sketch) toDense() {
+	s.regs = make([]uint8, s.m())
+	for _, es := range [][]uint32{s.list, s.tmp} {
+		for _, e := range es {
+			idx, rho := sparseToDense(e, s.p)
+			if rho > s.regs[idx] {
+				s.regs[idx] = rho
+			}
+		}
+	}
+	s.list, s.tmp = nil, nil
+}
+
+// Merge adds every element of other to s. Both sketches must have the same
+// precision.
+func (s *Sketch) Merge(other *Sketch) error {
+	if s.p != other.p {
+		return errMerge
+	}
+	other.flush()
+	if s.regs == nil && other.regs == nil {
+		s.flush()
+		s.list = mergeSparse(s.list, other.list)
+		if 4*len(s.list) > s.m() {
+			s.toDense()
+		}
+		return nil
+	}
+	if s.regs == nil {
+		s.toDense()
+	}
+	if other.regs != nil {
+		for i, r := range other.regs {
+			if r > s.regs[i] {
+				s.regs[i] = r
+			}
+		}
+		return nil
+	}
+	for _, e := range other.list {
+		idx, rho := sparseToDense(e, s.p)
+		if rho > s.regs[idx] {
+			s.regs[idx] = rho
+		}
+	}
+	return nil
+}
+
+// Estimate returns the estimated number of distinct elements added to the
+// sketch.
+func (s *Sketch) Estimate() uint64 {
+	if s.regs == nil {
+		s.flush()
+	}
+	if s.regs == nil {
+		// Linear counting over the 2^25 sparse registers, as in
+		// HyperLogLog++.
+		m := float64(uint32(1) << sparsePrecision)
+		return uint64(math.Floor(m*math.Log(m/(m-float64(len(s.list)))) + 0.5))
+	}
+	return uint64(math.Floor(s.dense() + 0.5))
+}
+
+// thresholds are, by precision minus MinPrecision, the largest linear
+// counting results HyperLogLog++ prefers over the bias corrected estimate.
+var thresholds = [MaxPrecision - MinPrecision + 1]float64{
+	10, 20, 40, 80, 220, 400, 900, 1800, 3100,
+	6500, 11500, 20000, 50000, 120000, 350000,
+}
+
+// dense returns the HyperLogLog++ estimate from the dense registers.
+func (s *Sketch) dense() float64 {
+	m := float64(s.m())
+	var sum float64
+	var zeros int
+	for _, r := range s.regs {
+		sum += 1 / float64(uint64(1)<<r)
+		if r == 0 {
+			zeros++
+		}
+	}
+	est := alpha(s.m()) * m * m / sum
+	if est <= 5*m {
+		est -= s.bias(est)
+	}
+	if zeros > 0 {
+		if lc := m * math.Log(m/float64(zeros)); lc <= thresholds[s.p-MinPrecision] {
+			return lc
+		}
+	}
+	return est
+}
+
+func alpha(m int) float64 {
+	switch m {
+	case 16:
+		return 0.673
+	case 32:
+		return 0.697
+	case 64:
+		return 0.709
+	}
+	return 0.7213 / (1 + 1.079/float64(m))
+}
+
+// biasNeighbors is how many of the nearest tabulated raw estimates have
+// their biases averaged, as in the paper.
+const biasNeighbors = 6
+
+// bias returns the estimated bias of the raw estimate est: the mean bias of
+// the biasNeighbors tabulated raw estimates closest to est. The tabulated
+// estimates are empirical means and not quite sorted, so they are all
+// scanned.
+func (s *Sketch) bias(est float64) float64 {
+	raws, biases := rawEstimates[s.p-MinPrecision], biases[s.p-MinPrecision]
+
+	// nearest holds the indices of the closest estimates seen so far,
+	// closest first.
+	var nearest [biasNeighbors]int
+	n := 0
+	for i, raw := range raws {
+		d := math.Abs(raw - est)
+		j := n
+		for j > 0 && d < math.Abs(raws[nearest[j-1]]-est) {
+			j--
+		}
+		if j == biasNeighbors {
+			continue
+		}
+		if n < biasNeighbors {
+			n++
+		}
+		copy(nearest[j+1:n], nearest[j:n-1])
+		nearest[j] = i
+	}
+	var sum float64
+	for _, i := range nearest {
+		sum += biases[i]
+	}
+	return sum / biasNeighbors
+}
+
+// MarshalBinary encodes the sketch. Sparse sketches are encoded as the
+// varint deltas between their sorted entries, and dense sketches as their
+// registers; either decodes to an identical sketch.
+func (s *Sketch) MarshalBinary() ([]byte, error) {
+	if s.regs == nil {
+		s.flush()
+	}
+	b := append([]byte(magic), s.p)
+	if s.regs != nil {
+		b = append(b, reprDense)
+		return append(b, s.regs...), nil
+	}
+	b = append(b, reprSparse)
+	b = appendUvarint(b, uint64(len(s.list)))
+	var prev uint32
+	for _, e := range s.list {
+		b = appendUvarint(b, uint64(e-prev))
+		prev = e
+	}
+	return b, nil
+}
+
+// UnmarshalBinary replaces the sketch with one encoded by MarshalBinary.
+func (s *Sketch) UnmarshalBinary(b []byte) error {
+	if len(b) < len(magic)+2 || string(b[:len(magic)]) != magic {
+		return errState
+	}
+	b = b[len(magic):]
+	p, repr := b[0], b[1]
+	b = b[2:]
+	if p < MinPrecision || p > MaxPrecision {
+		return errState
+	}
+	switch repr {
+	case reprDense:
+		if len(b) != 1<<p {
+			return errState
+		}
+		for _, r := range b {
+			if int(r) > 64-int(p)+1 {
+				return errState
+			}
+		}
+		*s = Sketch{p: p, regs: append([]uint8(nil), b...)}
+		return nil
+
+	case reprSparse:
+		n, b, ok := consumeUvarint(b)
+		if !ok || n > uint64(len(b)) {
+			return errState
+		}
+		list := make([]uint32, 0, n)
+		var prev uint64
+		for i := uint64(0); i < n; i++ {
+			var delta uint64
+			if delta, b, ok = consumeUvarint(b); !ok {
+				return errState
+			}
+			e := prev + delta
+			rho := e & (1<<rhoBits - 1)
+			if i > 0 && e>>rhoBits <= prev>>rhoBits ||
+				e>>(sparsePrecision+rhoBits) != 0 || rho == 0 || rho > maxSparseRho {
+				return errState
+			}
+			list = append(list, uint32(e))
+			prev = e
+		}
+		if len(b) != 0 {
+			return errState
+		}
+		*s = Sketch{p: p, list: list}
+		return nil
+	}
+	return errState
+}
+
+func appendUvarint(b []byte, x uint64) []byte {
+	var buf [binary.MaxVarintLen64]byte
+	return append(b, buf[:binary.PutUvarint(buf[:], x)]...)
+}
+
+func consumeUvarint(b []byte) (uint64, []byte, bool) {
+	x, n := binary.Uvarint(b)
+	if n <= 0 {
+		return 0, nil, false
+	}
+	return x, b[n:], true
+}
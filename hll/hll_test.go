@@ -0,0 +1,206 @@
+package hll
+
+import (
+	"bytes"
+	"math"
+	"sort"
+	"strconv"
+	"testing"
+)
+
+func TestNewPrecision(t *testing.T) {
+	for p := 0; p < 24; p++ {
+		_, err := New(uint8(p))
+		if ok := p >= MinPrecision && p <= MaxPrecision; ok != (err == nil) {
+			t.Errorf("New(%d): got err %v", p, err)
+		}
+	}
+}
+
+func TestSparseToDense(t *testing.T) {
+	// Registers built from sparse entries must match those built from the
+	// hashes directly, including hashes with no bits set below the index.
+	for p := uint8(MinPrecision); p <= MaxPrecision; p++ {
+		for _, h := range []uint64{
+			0,
+			1,
+			1 << (64 - p),
+			1 << (63 - p),
+			1 << (64 - sparsePrecision),
+			1 << (63 - sparsePrecision),
+			0xffffffffffffffff,
+			0x8000000000000000,
+			0x0123456789abcdef,
+			0xfedcba9876543210 >> p,
+		} {
+			idx, rho := denseEntry(h, p)
+			sidx, srho := sparseToDense(sparseEntry(h), p)
+			if idx != sidx || rho != srho {
+				t.Errorf("p %d, hash %016x: sparse gives (%d, %d) != dense (%d, %d)",
+					p, h, sidx, srho, idx, rho)
+			}
+		}
+	}
+}
+
+func TestEstimate(t *testing.T) {
+	for _, p := range []uint8{MinPrecision, 10, 14, MaxPrecision} {
+		s, _ := New(p)
+		stderr := 1.04 / math.Sqrt(float64(uint(1)<<p))
+		next := 0
+		for _, n := range []int{0, 1, 10, 100, 1000, 10000, 100000, 500000} {
+			for ; next < n; next++ {
+				s.AddString(strconv.Itoa(next))
+			}
+			// Sparse sketches are far more accurate than dense ones.
+			tolerance := 4 * stderr
+			if s.regs == nil {
+				tolerance = 0.01
+			}
+			est := float64(s.Estimate())
+			if n == 0 && est != 0 || n > 0 && math.Abs(est-float64(n))/float64(n) > tolerance {
+				t.Errorf("p %d: estimate %.0f for %d elements", p, est, n)
+			}
+		}
+	}
+}
+
+func TestBias(t *testing.T) {
+	mean := func(bs []float64) float64 {
+		var sum float64
+		for _, b := range bs {
+			sum += b
+		}
+		return sum / float64(len(bs))
+	}
+	for p := uint8(MinPrecision); p <= MaxPrecision; p++ {
+		s, _ := New(p)
+		raws, bs := rawEstimates[p-MinPrecision], biases[p-MinPrecision]
+		if len(raws) != len(bs) {
+			t.Fatalf("p=%d: tables differ in length", p)
+		}
+		n := len(raws)
+		for _, est := range []float64{0, raws[0], raws[n/3] + 0.1, raws[n/2], raws[n-1] + 1} {
+			idx := make([]int, n)
+			for i := range idx {
+				idx[i] = i
+			}
+			sort.SliceStable(idx, func(i, j int) bool {
+				return math.Abs(raws[idx[i]]-est) < math.Abs(raws[idx[j]]-est)
+			})
+			var near []float64
+			for _, i := range idx[:biasNeighbors] {
+				near = append(near, bs[i])
+			}
+			want := mean(near)
+			if got := s.bias(est); math.Abs(got-want) > 1e-9*math.Abs(want) {
+				t.Errorf("p=%d: bias(%v) = %v, want %v", p, est, got, want)
+			}
+		}
+	}
+}
+
+func TestAddDuplicates(t *testing.T) {
+	s, _ := New(14)
+	for i := 0; i < 10; i++ {
+		for j := 0; j < 100; j++ {
+			s.Add([]byte(strconv.Itoa(j)))
+		}
+	}
+	if est := s.Estimate(); est != 100 {
+		t.Errorf("estimate %d for 100 repeated elements", est)
+	}
+}
+
+func TestMerge(t *testing.T) {
+	for _, sizes := range [][2]int{
+		{10, 20},       // sparse into sparse
+		{10, 100000},   // dense into sparse
+		{100000, 10},   // sparse into dense
+		{50000, 80000}, // dense into dense
+	} {
+		a, _ := New(12)
+		b, _ := New(12)
+		all, _ := New(12)
+		for i := 0; i < sizes[0]; i++ {
+			a.AddString(strconv.Itoa(i))
+			all.AddString(strconv.Itoa(i))
+		}
+		for i := 0; i < sizes[1]; i++ {
+			b.AddString("b" + strconv.Itoa(i))
+			all.AddString("b" + strconv.Itoa(i))
+		}
+		if err := a.Merge(b); err != nil {
+			t.Fatal(err)
+		}
+		if got, exp := a.Estimate(), all.Estimate(); got != exp {
+			t.Errorf("%v: merged estimate %d != %d", sizes, got, exp)
+		}
+	}
+
+	a, _ := New(12)
+	b, _ := New(13)
+	if err := a.Merge(b); err == nil {
+		t.Error("merge of differing precisions succeeded")
+	}
+}
+
+func TestMarshalBinary(t *testing.T) {
+	for _, n := range []int{0, 50, 100000} {
+		s, _ := New(12)
+		for i := 0; i < n; i++ {
+			s.AddString(strconv.Itoa(i))
+		}
+		b, err := s.MarshalBinary()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var u Sketch
+		if err := u.UnmarshalBinary(b); err != nil {
+			t.Fatalf("%d elements: %v", n, err)
+		}
+		if u.Precision() != s.Precision() || u.Estimate() != s.Estimate() {
+			t.Errorf("%d elements: unmarshaled (%d, %d) != (%d, %d)",
+				n, u.Precision(), u.Estimate(), s.Precision(), s.Estimate())
+		}
+		if b2, _ := u.MarshalBinary(); !bytes.Equal(b, b2) {
+			t.Errorf("%d elements: encoding not stable across a round trip", n)
+		}
+
+		if err := u.UnmarshalBinary(b[:len(b)-1]); err == nil && n > 0 {
+			t.Errorf("%d elements: truncated encoding accepted", n)
+		}
+	}
+
+	// Sparse entries must be strictly increasing by index.
+	bad := append([]byte(magic), 12, reprSparse, 2, 1<<rhoBits|1, 1)
+	var s Sketch
+	if err := s.UnmarshalBinary(bad); err == nil {
+		t.Error("duplicate sparse index accepted")
+	}
+
+	// As must rho, which a crafted encoding could otherwise push past the
+	// registers' range.
+	bad = append([]byte(magic), 12, reprSparse, 1, maxSparseRho+1)
+	if err := s.UnmarshalBinary(bad); err == nil {
+		t.Error("sparse rho out of range accepted")
+	}
+	bad = append([]byte(magic), 12, reprSparse, 1, maxSparseRho)
+	if err := s.UnmarshalBinary(bad); err != nil {
+		t.Errorf("largest sparse rho rejected: %v", err)
+	}
+	s.Estimate()
+}
+
+func BenchmarkAddString(b *testing.B) {
+	s, _ := New(14)
+	keys := make([]string, 1024)
+	for i := range keys {
+		keys[i] = strconv.Itoa(i)
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		s.AddString(keys[i%len(keys)])
+	}
+}
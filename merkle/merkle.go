@@ -0,0 +1,200 @@
+// Package merkle provides a fixed depth Merkle tree over key/value pairs, for
+// finding the key ranges in which two replicas of a data set differ.
+//
+// A tree of depth d has 2^d leaves, each covering an equal range of 64 bit key
+// hashes: a key belongs to the leaf numbered by the top d bits of its
+// murmur3.StringSum64. A leaf's hash is the lane wise sum of the 128 bit
+// hashes of its pairs, so pairs can be updated and deleted in any order. An
+// inner node's hash is murmur3.Sum128 of its children's hashes, as the 32
+// little endian bytes of the left h1 and h2 and then the right h1 and h2,
+// computed with murmur3.Mix128Block without encoding them.
+//
+// Replicas compare roots, and where roots differ, walk down to the differing
+// leaves, either locally with Diff or remotely by exchanging Node hashes.
+package merkle
+
+import (
+	"errors"
+
+	"github.com/twmb/murmur3"
+)
+
+// MaxDepth is the depth of the largest tree New builds, 2^20 leaves.
+const MaxDepth = 20
+
+var (
+	errDepth = errors.New("merkle: depth out of range")
+	errShape = errors.New("merkle: trees have different depths")
+)
+
+// Tree is a fixed depth Merkle tree. The zero value is not usable; create
+// trees with New.
+//
+// A Tree is not safe for concurrent use.
+type Tree struct {
+	depth uint
+	// nodes holds the tree in heap order: the root at 1, the children of
+	// node i at 2i and 2i+1, and the leaves from 1<<depth on.
+	nodes   [][2]uint64
+	entries map[string][2]uint64
+}
+
+// New returns an empty tree with 2^depth leaves. Depth must be at most
+// MaxDepth.
+func New(depth int) (*Tree, error) {
+	if depth < 0 || depth > MaxDepth {
+		return nil, errDepth
+	}
+	t := &Tree{
+		depth:   uint(depth),
+		nodes:   make([][2]uint64, 2<<uint(depth)),
+		entries: make(map[string][2]uint64),
+	}
+	for i := len(t.nodes)/2 - 1; i >= 1; i-- {
+		t.nodes[i] = combine(t.nodes[2*i], t.nodes[2*i+1])
+	}
+	return t, nil
+}
+
+// Depth returns the depth of the tree.
+func (t *Tree) Depth() int { return int(t.depth) }
+
+// Len returns the number of keys in the tree.
+func (t *Tree) Len() int { return len(t.entries) }
+
+// combine returns the hash of an inner node with the given children:
+// murmur3.Sum128 of their 32 little endian bytes.
+func combine(l, r [2]uint64) [2]uint64 {
+	h1, h2 := murmur3.Mix128Block(0, 0, l[0], l[1])
+	h1, h2 = murmur3.Mix128Block(h1, h2, r[0], r[1])
+	h1 ^= 32
+	h2 ^= 32
+	h1 += h2
+	h2 += h1
+	h1 = murmur3.Fmix64(h1)
+	h2 = murmur3.Fmix64(h2)
+	h1 += h2
+	h2 += h1
+	return [2]uint64{h1, h2}
+}
+
+// entryHash returns the hash of a pair: the value hashed with the key's hash
+// as seeds.
+func entryHash(key, value string) [2]uint64 {
+	k1, k2 := murmur3.StringSum128(key)
+	h1, h2 := murmur3.SeedStringSum128(k1, k2, value)
+	return [2]uint64{h1, h2}
+}
+
+func (t *Tree) leaf(key string) int {
+	if t.depth == 0 {
+		return 0
+	}
+	return int(murmur3.StringSum64(key) >> (64 - t.depth))
+}
+
+// adjust adds add and subtracts sub from the leaf of key, rehashing the path
+// to the root.
+func (t *Tree) adjust(key string, add, sub [2]uint64) {
+	i := 1<<t.depth + t.leaf(key)
+	t.nodes[i][0] += add[0] - sub[0]
+	t.nodes[i][1] += add[1] - sub[1]
+	for i /= 2; i >= 1; i /= 2 {
+		t.nodes[i] = combine(t.nodes[2*i], t.nodes[2*i+1])
+	}
+}
+
+// Update sets the value of key, adding the key if it is new.
+func (t *Tree) Update(key, value string) {
+	h := entryHash(key, value)
+	old := t.entries[key]
+	if h == old {
+		return
+	}
+	t.entries[key] = h
+	t.adjust(key, h, old)
+}
+
+// Delete removes key, if it exists.
+func (t *Tree) Delete(key string) {
+	old, ok := t.entries[key]
+	if !ok {
+		return
+	}
+	delete(t.entries, key)
+	t.adjust(key, [2]uint64{}, old)
+}
+
+// Root returns the hash of the root.
+func (t *Tree) Root() (h1, h2 uint64) {
+	return t.nodes[1][0], t.nodes[1][1]
+}
+
+// Equal returns whether two trees have the same depth and root, and thus,
+// barring collisions, the same pairs.
+func (t *Tree) Equal(other *Tree) bool {
+	return t.depth == other.depth && t.nodes[1] == other.nodes[1]
+}
+
+// Node returns the hash of node index at the given level, where level 0 is
+// the root and level Depth holds the leaves, numbered from 0 in key hash
+// order. It panics if the node does not exist.
+func (t *Tree) Node(level, index int) (h1, h2 uint64) {
+	if level < 0 || level > int(t.depth) || index < 0 || index >= 1<<uint(level) {
+		panic("merkle: node out of range")
+	}
+	n := t.nodes[1<<uint(level)+index]
+	return n[0], n[1]
+}
+
+// Range is an inclusive range of 64 bit key hashes, as returned by
+// murmur3.StringSum64.
+type Range struct {
+	Start, End uint64
+}
+
+// Contains returns whether key hashes into the range.
+func (r Range) Contains(key string) bool {
+	h := murmur3.StringSum64(key)
+	return r.Start <= h && h <= r.End
+}
+
+// LeafRange returns the range of key hashes covered by leaf index.
+func (t *Tree) LeafRange(index int) Range {
+	if t.depth == 0 {
+		return Range{0, 1<<64 - 1}
+	}
+	width := uint64(1) << (64 - t.depth)
+	start := uint64(index) * width
+	return Range{start, start + width - 1}
+}
+
+// Diff returns the ranges of key hashes in which the trees differ, in order,
+// with adjacent differing leaves merged into one range. Keys whose pairs
+// differ between the trees lie within the ranges; everything outside of them
+// is identical, barring collisions.
+func (t *Tree) Diff(other *Tree) ([]Range, error) {
+	if t.depth != other.depth {
+		return nil, errShape
+	}
+	var ranges []Range
+	var walk func(i int)
+	walk = func(i int) {
+		if t.nodes[i] == other.nodes[i] {
+			return
+		}
+		if i < 1<<t.depth {
+			walk(2 * i)
+			walk(2*i + 1)
+			return
+		}
+		r := t.LeafRange(i - 1<<t.depth)
+		if n := len(ranges); n > 0 && ranges[n-1].End+1 == r.Start {
+			ranges[n-1].End = r.End
+			return
+		}
+		ranges = append(ranges, r)
+	}
+	walk(1)
+	return ranges, nil
+}
@@ -0,0 +1,160 @@
+package merkle
+
+import (
+	"encoding/binary"
+	"strconv"
+	"testing"
+	"testing/quick"
+
+	"github.com/twmb/murmur3"
+)
+
+func TestCombine(t *testing.T) {
+	if err := quick.Check(func(l, r [2]uint64) bool {
+		var b [32]byte
+		binary.LittleEndian.PutUint64(b[0:], l[0])
+		binary.LittleEndian.PutUint64(b[8:], l[1])
+		binary.LittleEndian.PutUint64(b[16:], r[0])
+		binary.LittleEndian.PutUint64(b[24:], r[1])
+		h1, h2 := murmur3.Sum128(b[:])
+		return combine(l, r) == [2]uint64{h1, h2}
+	}, nil); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestNew(t *testing.T) {
+	for _, depth := range []int{-1, MaxDepth + 1} {
+		if _, err := New(depth); err == nil {
+			t.Errorf("New(%d) succeeded", depth)
+		}
+	}
+	a, _ := New(0)
+	b, _ := New(0)
+	if !a.Equal(b) {
+		t.Error("empty trees differ")
+	}
+	a.Update("k", "v")
+	if a.Equal(b) || a.Len() != 1 {
+		t.Error("update did not change a depth 0 tree")
+	}
+	if r, _ := a.Diff(b); len(r) != 1 || r[0] != (Range{0, 1<<64 - 1}) {
+		t.Errorf("depth 0 Diff = %v", r)
+	}
+}
+
+func TestUpdateDelete(t *testing.T) {
+	a, _ := New(8)
+	b, _ := New(8)
+	empty, _ := New(8)
+
+	// Order does not matter, and overwritten or deleted pairs leave no
+	// trace.
+	for i := 0; i < 1000; i++ {
+		a.Update(strconv.Itoa(i), "v"+strconv.Itoa(i))
+	}
+	for i := 999; i >= 0; i-- {
+		b.Update(strconv.Itoa(i), "old")
+		b.Update("gone"+strconv.Itoa(i), "x")
+		b.Update(strconv.Itoa(i), "v"+strconv.Itoa(i))
+		b.Delete("gone" + strconv.Itoa(i))
+	}
+	b.Delete("never added")
+	if !a.Equal(b) || a.Len() != 1000 || b.Len() != 1000 {
+		t.Fatalf("trees with the same pairs differ")
+	}
+	if h1, h2 := a.Root(); h1 == 0 && h2 == 0 {
+		t.Error("zero root")
+	}
+
+	for i := 0; i < 1000; i++ {
+		a.Delete(strconv.Itoa(i))
+	}
+	if !a.Equal(empty) {
+		t.Error("tree with every pair deleted differs from an empty tree")
+	}
+}
+
+func TestDiff(t *testing.T) {
+	a, _ := New(10)
+	b, _ := New(10)
+	for i := 0; i < 5000; i++ {
+		a.Update(strconv.Itoa(i), "v")
+		b.Update(strconv.Itoa(i), "v")
+	}
+	if r, _ := a.Diff(b); len(r) != 0 {
+		t.Fatalf("identical trees: Diff = %v", r)
+	}
+
+	changed := []string{"17", "2048", "4999", "new key"}
+	a.Update("17", "changed")
+	a.Delete("2048")
+	b.Delete("4999")
+	a.Update("new key", "v")
+
+	ranges, err := a.Diff(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ranges) == 0 || len(ranges) > len(changed) {
+		t.Fatalf("Diff = %v", ranges)
+	}
+	for _, key := range changed {
+		var found bool
+		for _, r := range ranges {
+			found = found || r.Contains(key)
+		}
+		if !found {
+			t.Errorf("changed key %q outside of every differing range", key)
+		}
+	}
+	for i := 1; i < len(ranges); i++ {
+		if ranges[i].Start <= ranges[i-1].End+1 {
+			t.Errorf("ranges %v and %v overlap, touch or are out of order", ranges[i-1], ranges[i])
+		}
+	}
+
+	// Walking the leaves by hand finds exactly those the ranges cover.
+	var leaves, covered uint64
+	for _, r := range ranges {
+		covered += (r.End-r.Start)>>(64-10) + 1
+	}
+	for i := 0; i < 1<<10; i++ {
+		a1, a2 := a.Node(10, i)
+		b1, b2 := b.Node(10, i)
+		if a1 != b1 || a2 != b2 {
+			leaves++
+		}
+	}
+	if leaves != covered {
+		t.Errorf("%d differing leaves, but ranges %v cover %d", leaves, ranges, covered)
+	}
+
+	small, _ := New(9)
+	if _, err := a.Diff(small); err == nil {
+		t.Error("Diff of different depths succeeded")
+	}
+}
+
+func TestLeafRange(t *testing.T) {
+	tr, _ := New(4)
+	for i := 0; i < 16; i++ {
+		r := tr.LeafRange(i)
+		if r.Start != uint64(i)<<60 || r.End != uint64(i)<<60|(1<<60-1) {
+			t.Errorf("LeafRange(%d) = %x", i, r)
+		}
+	}
+	for i := 0; i < 100; i++ {
+		key := strconv.Itoa(i)
+		if !tr.LeafRange(tr.leaf(key)).Contains(key) {
+			t.Errorf("key %s outside of its leaf's range", key)
+		}
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("Node out of range did not panic")
+		}
+	}()
+	tr.Node(1, 2)
+}
@@ -0,0 +1,140 @@
+package minhash
+
+import (
+	"encoding/binary"
+	"math"
+	"sort"
+
+	"github.com/twmb/murmur3"
+)
+
+// Index is a locality sensitive hashing index over signatures. Each signature
+// is cut into bands of rows slots, and two signatures become candidates if
+// any of their bands are identical. Pairs with a Jaccard similarity s are
+// found with probability 1 - (1 - s^rows)^bands, an S curve that rises
+// steeply around Threshold(bands, rows).
+//
+// An Index is not safe for concurrent use.
+type Index struct {
+	bands, rows int
+	buckets     []map[uint64][]string // per band
+	ids         map[string]struct{}
+	buf         []byte
+}
+
+// NewIndex returns an empty index over signatures of at least bands*rows
+// slots. Both are raised to at least one.
+func NewIndex(bands, rows int) *Index {
+	if bands < 1 {
+		bands = 1
+	}
+	if rows < 1 {
+		rows = 1
+	}
+	ix := &Index{
+		bands:   bands,
+		rows:    rows,
+		buckets: make([]map[uint64][]string, bands),
+		ids:     make(map[string]struct{}),
+		buf:     make([]byte, 8*rows),
+	}
+	for i := range ix.buckets {
+		ix.buckets[i] = make(map[uint64][]string)
+	}
+	return ix
+}
+
+// Threshold returns the approximate Jaccard similarity at which an index of
+// the given shape is as likely as not to pair two signatures: (1/bands) ^
+// (1/rows).
+func Threshold(bands, rows int) float64 {
+	return math.Pow(1/float64(bands), 1/float64(rows))
+}
+
+// bandKey returns the hash of band b of sig, seeded by the band number so
+// that identical slot values in different bands do not collide.
+func (ix *Index) bandKey(sig Signature, b int) uint64 {
+	for i, v := range sig[b*ix.rows : (b+1)*ix.rows] {
+		binary.LittleEndian.PutUint64(ix.buf[8*i:], v)
+	}
+	return murmur3.SeedSum64(uint64(b), ix.buf)
+}
+
+func (ix *Index) check(sig Signature) {
+	if len(sig) < ix.bands*ix.rows {
+		panic("minhash: signature shorter than the index's bands*rows")
+	}
+}
+
+// Add indexes sig under id. Adding an id that is already indexed does
+// nothing.
+//
+// Add panics if sig is shorter than bands*rows.
+func (ix *Index) Add(id string, sig Signature) {
+	ix.check(sig)
+	if _, exists := ix.ids[id]; exists {
+		return
+	}
+	ix.ids[id] = struct{}{}
+	for b := range ix.buckets {
+		key := ix.bandKey(sig, b)
+		ix.buckets[b][key] = append(ix.buckets[b][key], id)
+	}
+}
+
+// Len returns the number of indexed ids.
+func (ix *Index) Len() int { return len(ix.ids) }
+
+// Query returns the sorted ids that share at least one band with sig.
+//
+// Query panics if sig is shorter than bands*rows.
+func (ix *Index) Query(sig Signature) []string {
+	ix.check(sig)
+	seen := make(map[string]struct{})
+	for b := range ix.buckets {
+		for _, id := range ix.buckets[b][ix.bandKey(sig, b)] {
+			seen[id] = struct{}{}
+		}
+	}
+	ids := make([]string, 0, len(seen))
+	for id := range seen {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// Pair is a candidate pair of ids, with A < B.
+type Pair struct {
+	A, B string
+}
+
+// Candidates returns every pair of indexed ids that share at least one band,
+// sorted.
+func (ix *Index) Candidates() []Pair {
+	seen := make(map[Pair]struct{})
+	for _, buckets := range ix.buckets {
+		for _, ids := range buckets {
+			for i := range ids {
+				for j := i + 1; j < len(ids); j++ {
+					p := Pair{ids[i], ids[j]}
+					if p.B < p.A {
+						p.A, p.B = p.B, p.A
+					}
+					seen[p] = struct{}{}
+				}
+			}
+		}
+	}
+	pairs := make([]Pair, 0, len(seen))
+	for p := range seen {
+		pairs = append(pairs, p)
+	}
+	sort.Slice(pairs, func(i, j int) bool {
+		if pairs[i].A != pairs[j].A {
+			return pairs[i].A < pairs[j].A
+		}
+		return pairs[i].B < pairs[j].B
+	})
+	return pairs
+}
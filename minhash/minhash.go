@@ -0,0 +1,111 @@
+// Package minhash computes MinHash signatures of sets, estimates the Jaccard
+// similarity of sets from their signatures, and finds candidate near
+// duplicates with locality sensitive hashing.
+//
+// Every element is hashed once with murmur3.StringSum128. KPermutation
+// derives its k hash functions from the two halves of that sum, while
+// OnePermutation uses the first half to pick one of k bins and the second as
+// the value within it, filling empty bins by optimal densification
+// (Shrivastava, "Optimal Densification for Fast and Accurate Minwise
+// Hashing", 2017). Only signatures made by the same function with the same k
+// can be compared.
+package minhash
+
+import (
+	"math"
+
+	"github.com/twmb/murmur3"
+)
+
+// Signature is a MinHash signature.
+type Signature []uint64
+
+// empty is the value of every slot of the signature of an empty set.
+const empty = math.MaxUint64
+
+// KPermutation returns the k slot signature of the set of elements, where
+// slot i is the minimum over elements of the ith hash function. Hash function
+// i is murmur3.Fmix64(h1 + i*h2) over the element's StringSum128.
+func KPermutation(k int, elements []string) Signature {
+	sig := newSignature(k)
+	for _, e := range elements {
+		h1, h2 := murmur3.StringSum128(e)
+		for i := range sig {
+			if v := murmur3.Fmix64(h1 + uint64(i)*h2); v < sig[i] {
+				sig[i] = v
+			}
+		}
+	}
+	return sig
+}
+
+// OnePermutation returns the k slot signature of the set of elements
+// computed with a single hash per element, which is about k times faster than
+// KPermutation for large sets. Its estimates are as accurate as
+// KPermutation's once the set has a few times k elements.
+func OnePermutation(k int, elements []string) Signature {
+	sig := newSignature(k)
+	k = len(sig) // newSignature clamps k
+	filled := make([]bool, k)
+	var nfilled int
+	for _, e := range elements {
+		h1, h2 := murmur3.StringSum128(e)
+		bin := h1 % uint64(k)
+		if h2 < sig[bin] {
+			sig[bin] = h2
+		}
+		if !filled[bin] {
+			filled[bin] = true
+			nfilled++
+		}
+	}
+	if nfilled == 0 || nfilled == k {
+		return sig
+	}
+	// Each empty bin copies the first filled bin of its own sequence of
+	// probes, so that equal sets of filled bins densify identically.
+	for i := range sig {
+		if filled[i] {
+			continue
+		}
+		for attempt := uint64(1); ; attempt++ {
+			probe := murmur3.Fmix64(uint64(i)<<32|attempt) % uint64(k)
+			if filled[probe] {
+				sig[i] = sig[probe]
+				break
+			}
+		}
+	}
+	return sig
+}
+
+// newSignature returns a signature of k empty slots, or of one slot if k is
+// less than 1.
+func newSignature(k int) Signature {
+	if k < 1 {
+		k = 1
+	}
+	sig := make(Signature, k)
+	for i := range sig {
+		sig[i] = empty
+	}
+	return sig
+}
+
+// Jaccard estimates the Jaccard similarity of the sets two signatures were
+// computed from: the fraction of slots in which they agree. Two signatures of
+// empty sets are identical.
+//
+// Jaccard panics if the signatures differ in length.
+func Jaccard(a, b Signature) float64 {
+	if len(a) != len(b) {
+		panic("minhash: Jaccard of signatures with different lengths")
+	}
+	var equal int
+	for i := range a {
+		if a[i] == b[i] {
+			equal++
+		}
+	}
+	return float64(equal) / float64(len(a))
+}
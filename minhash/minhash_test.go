@@ -0,0 +1,119 @@
+package minhash
+
+import (
+	"math"
+	"strconv"
+	"testing"
+)
+
+// sets returns two sets of n elements sharing shared of them.
+func sets(n, shared int, prefix string) ([]string, []string) {
+	a := make([]string, 0, n)
+	b := make([]string, 0, n)
+	for i := 0; i < shared; i++ {
+		a = append(a, prefix+"s"+strconv.Itoa(i))
+		b = append(b, prefix+"s"+strconv.Itoa(i))
+	}
+	for i := shared; i < n; i++ {
+		a = append(a, prefix+"a"+strconv.Itoa(i))
+		b = append(b, prefix+"b"+strconv.Itoa(i))
+	}
+	return a, b
+}
+
+func TestJaccard(t *testing.T) {
+	const k = 256
+	for _, sign := range []struct {
+		name string
+		fn   func(int, []string) Signature
+	}{
+		{"KPermutation", KPermutation},
+		{"OnePermutation", OnePermutation},
+	} {
+		for _, shared := range []int{0, 200, 500, 800, 1000} {
+			a, b := sets(1000, shared, "")
+			exp := float64(shared) / float64(2000-shared)
+			got := Jaccard(sign.fn(k, a), sign.fn(k, b))
+			// Three standard errors of a k slot estimate.
+			if tol := 3 * math.Sqrt(exp*(1-exp)/k); math.Abs(got-exp) > tol+1e-9 {
+				t.Errorf("%s, %d shared: Jaccard %.3f, exp %.3f", sign.name, shared, got, exp)
+			}
+		}
+	}
+}
+
+func TestSmallSets(t *testing.T) {
+	// With far fewer elements than bins, most bins are densified; equal
+	// sets must still produce equal signatures, regardless of order.
+	a := OnePermutation(64, []string{"x", "y", "z"})
+	b := OnePermutation(64, []string{"z", "x", "y"})
+	if Jaccard(a, b) != 1 {
+		t.Errorf("equal small sets: Jaccard %.3f", Jaccard(a, b))
+	}
+	for i, v := range a {
+		if v == empty {
+			t.Fatalf("slot %d left empty", i)
+		}
+	}
+	if got := Jaccard(a, OnePermutation(64, []string{"p", "q", "r"})); got > 0.2 {
+		t.Errorf("disjoint small sets: Jaccard %.3f", got)
+	}
+
+	if got := Jaccard(KPermutation(8, nil), OnePermutation(8, nil)); got != 1 {
+		t.Errorf("empty sets: Jaccard %.3f", got)
+	}
+
+	// Non-positive k is clamped to one slot by both functions.
+	for _, k := range []int{0, -1} {
+		elements := []string{"x", "y"}
+		if got := len(KPermutation(k, elements)); got != 1 {
+			t.Errorf("KPermutation(%d): %d slots", k, got)
+		}
+		if got := len(OnePermutation(k, elements)); got != 1 {
+			t.Errorf("OnePermutation(%d): %d slots", k, got)
+		}
+	}
+}
+
+func TestIndex(t *testing.T) {
+	const bands, rows = 20, 5
+	ix := NewIndex(bands, rows)
+
+	// Pairs of documents at decreasing similarity; only the similar pairs
+	// should be paired by an index thresholded near 0.55.
+	similar := map[Pair]bool{}
+	for i, shared := range []int{950, 900, 850, 100, 50, 0} {
+		p := "d" + strconv.Itoa(i)
+		a, b := sets(1000, shared, p)
+		pair := Pair{p + "a", p + "b"}
+		ix.Add(pair.A, KPermutation(bands*rows, a))
+		ix.Add(pair.B, KPermutation(bands*rows, b))
+		similar[pair] = shared >= 850
+	}
+	if ix.Len() != 12 {
+		t.Errorf("Len = %d, want 12", ix.Len())
+	}
+
+	got := map[Pair]bool{}
+	for _, p := range ix.Candidates() {
+		got[p] = true
+	}
+	for pair, want := range similar {
+		if got[pair] != want {
+			t.Errorf("pair %v: candidate %v, want %v", pair, got[pair], want)
+		}
+		delete(got, pair)
+	}
+	for pair := range got {
+		t.Errorf("unexpected candidate %v", pair)
+	}
+
+	a, _ := sets(1000, 950, "d0")
+	if q := ix.Query(KPermutation(bands*rows, a)); len(q) != 2 || q[0] != "d0a" || q[1] != "d0b" {
+		t.Errorf("Query = %v, want [d0a d0b]", q)
+	}
+
+	if th := Threshold(bands, rows); math.Abs(th-0.549) > 0.001 {
+		t.Errorf("Threshold = %.3f", th)
+	}
+}
@@ -6,11 +6,44 @@
 // implementation of the murmur3 hash algorithm for strings and slices.
 //
 // Assembly is provided for amd64 go1.5+; pull requests are welcome for other
-// architectures.
+// architectures. Untested arm64 assembly is built only with the
+// murmur3_arm64asm build tag.
+//
+// Every streaming hasher also implements io.StringWriter, to hash strings
+// without converting them, and io.ReaderFrom, so that io.Copy into a hasher
+// mixes whole blocks straight out of a single read buffer.
 package murmur3
 
+import (
+	"errors"
+	"io"
+	"sync"
+)
+
+// Magic prefixes identifying the kind and version of a marshaled digest state,
+// in the spirit of crypto/sha256. Any change to a marshaled layout must use a
+// new magic.
+const (
+	magic32  = "mm3\x01"
+	magic64  = "mm3\x02"
+	magic128 = "mm3\x03"
+
+	magic128x86 = "mm3\x04"
+)
+
+var (
+	errStateIdentifier = errors.New("murmur3: invalid hash state identifier")
+	errStateSize       = errors.New("murmur3: invalid hash state size")
+)
+
+// stringWriter is io.StringWriter, which only exists as of go1.12.
+type stringWriter interface {
+	WriteString(s string) (n int, err error)
+}
+
 type bmixer interface {
 	bmix(p []byte) (tail []byte)
+	bmixString(p string) (tail string)
 	Size() (n int)
 	reset()
 }
@@ -51,8 +84,144 @@ func (d *digest) Write(p []byte) (n int, err error) {
 	return n, nil
 }
 
+// WriteString is Write for strings, hashing s without converting it to a byte
+// slice.
+func (d *digest) WriteString(s string) (n int, err error) {
+	n = len(s)
+	d.clen += n
+
+	if len(d.tail) > 0 {
+		// Complete the pending block, or keep growing it if s is too
+		// short; either way it stays within buf.
+		nfree := d.Size() - len(d.tail)
+		if nfree > len(s) {
+			nfree = len(s)
+		}
+		block := append(d.tail, s[:nfree]...)
+		s = s[nfree:]
+		if len(block) < d.Size() {
+			d.tail = block
+			return n, nil
+		}
+		_ = d.bmix(block) // No tail.
+	}
+
+	tail := d.bmixString(s)
+	nn := copy(d.buf[:], tail)
+	d.tail = d.buf[:nn]
+
+	return n, nil
+}
+
+// readFromBufs holds the buffers ReadFrom reads into. Their size is a
+// multiple of every block size.
+var readFromBufs = sync.Pool{
+	New: func() interface{} { return new([32 << 10]byte) },
+}
+
+// ReadFrom hashes everything r returns until io.EOF, reading directly into a
+// pooled buffer whose whole blocks are mixed in place. It returns the number
+// of bytes hashed and any error other than io.EOF; bytes read before an error
+// are hashed.
+func (d *digest) ReadFrom(r io.Reader) (n int64, err error) {
+	buf := readFromBufs.Get().(*[32 << 10]byte)
+	defer readFromBufs.Put(buf)
+
+	// Pending bytes lead the buffer, so that every read extends a run
+	// starting on a block boundary.
+	filled := copy(buf[:], d.tail)
+	for {
+		var nr int
+		nr, err = r.Read(buf[filled:])
+		if nr > 0 {
+			n += int64(nr)
+			d.clen += nr
+			filled += nr
+			tail := d.bmix(buf[:filled])
+			filled = copy(buf[:], tail)
+		}
+		if err != nil {
+			break
+		}
+	}
+	d.tail = d.buf[:copy(d.buf[:], buf[:filled])]
+
+	if err == io.EOF {
+		err = nil
+	}
+	return n, err
+}
+
 func (d *digest) Reset() {
 	d.clen = 0
 	d.tail = nil
 	d.bmixer.reset()
 }
+
+// appendState appends the cumulative length followed by the Size() bytes of
+// the pending block, zero padded beyond the tail.
+func (d *digest) appendState(b []byte) []byte {
+	b = appendUint64(b, uint64(d.clen))
+	b = append(b, d.tail...)
+	for i := len(d.tail); i < d.Size(); i++ {
+		b = append(b, 0)
+	}
+	return b
+}
+
+// validState reports whether b, the 8+size bytes written by appendState for a
+// digest of the given Size, holds a length that fits in an int and only zero
+// padding beyond the tail that length implies. Unmarshaling checks this before
+// changing anything.
+func validState(b []byte, size int) bool {
+	b, clen := consumeUint64(b)
+	if clen > uint64(maxInt) {
+		return false
+	}
+	for _, c := range b[clen%uint64(size):] {
+		if c != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+const maxInt = int(^uint(0) >> 1)
+
+// consumeState restores the state written by appendState; b must be exactly
+// 8+Size() bytes long and pass validState.
+func (d *digest) consumeState(b []byte) {
+	b, clen := consumeUint64(b)
+	d.clen = int(clen)
+	copy(d.buf[:], b)
+	d.tail = d.buf[:d.clen%d.Size()]
+}
+
+func appendUint32(b []byte, x uint32) []byte {
+	return append(b, byte(x>>24), byte(x>>16), byte(x>>8), byte(x))
+}
+
+func appendUint64(b []byte, x uint64) []byte {
+	return append(b,
+		byte(x>>56), byte(x>>48), byte(x>>40), byte(x>>32),
+		byte(x>>24), byte(x>>16), byte(x>>8), byte(x),
+	)
+}
+
+func appendUint64LE(b []byte, x uint64) []byte {
+	return append(b,
+		byte(x), byte(x>>8), byte(x>>16), byte(x>>24),
+		byte(x>>32), byte(x>>40), byte(x>>48), byte(x>>56),
+	)
+}
+
+func consumeUint32(b []byte) ([]byte, uint32) {
+	x := uint32(b[3]) | uint32(b[2])<<8 | uint32(b[1])<<16 | uint32(b[0])<<24
+	return b[4:], x
+}
+
+func consumeUint64(b []byte) ([]byte, uint64) {
+	x := uint64(b[7]) | uint64(b[6])<<8 | uint64(b[5])<<16 | uint64(b[4])<<24 |
+		uint64(b[3])<<32 | uint64(b[2])<<40 | uint64(b[1])<<48 | uint64(b[0])<<56
+	return b[8:], x
+}
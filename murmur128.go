@@ -1,7 +1,9 @@
 package murmur3
 
 import (
+	"encoding"
 	"hash"
+	"io"
 	"math/bits"
 )
 
@@ -15,6 +17,12 @@ var (
 	_ hash.Hash = new(digest128)
 	_ Hash128   = new(digest128)
 	_ bmixer    = new(digest128)
+
+	_ encoding.BinaryMarshaler   = new(digest128)
+	_ encoding.BinaryUnmarshaler = new(digest128)
+
+	_ io.ReaderFrom = new(digest128)
+	_ stringWriter  = new(digest128)
 )
 
 // Hash128 provides an interface for a streaming 128 bit hash.
@@ -37,6 +45,9 @@ type digest128 struct {
 //
 // The canonical implementation allows one only uint32 seed; to imitate that
 // behavior, use the same, uint32-max seed for seed1 and seed2.
+//
+// The returned hash also implements encoding.BinaryMarshaler and
+// encoding.BinaryUnmarshaler to marshal and unmarshal its internal state.
 func SeedNew128(seed1, seed2 uint64) Hash128 {
 	d := &digest128{seed1: seed1, seed2: seed2}
 	d.bmixer = d
@@ -64,31 +75,61 @@ func (d *digest128) Sum(b []byte) []byte {
 	)
 }
 
+// marshaledSize128 is the magic, both seeds, h1, h2, clen and a full block.
+const marshaledSize128 = len(magic128) + 8 + 8 + 8 + 8 + 8 + 16
+
+func (d *digest128) MarshalBinary() ([]byte, error) { return d.marshal(magic128), nil }
+
+func (d *digest128) UnmarshalBinary(b []byte) error { return d.unmarshal(magic128, b) }
+
+// marshal and unmarshal are shared with digest64, which differs only in its
+// magic.
+func (d *digest128) marshal(magic string) []byte {
+	b := make([]byte, 0, marshaledSize128)
+	b = append(b, magic...)
+	b = appendUint64(b, d.seed1)
+	b = appendUint64(b, d.seed2)
+	b = appendUint64(b, d.h1)
+	b = appendUint64(b, d.h2)
+	return d.appendState(b)
+}
+
+func (d *digest128) unmarshal(magic string, b []byte) error {
+	if len(b) < len(magic) || string(b[:len(magic)]) != magic {
+		return errStateIdentifier
+	}
+	if len(b) != marshaledSize128 || !validState(b[len(b)-8-d.Size():], d.Size()) {
+		return errStateSize
+	}
+	b = b[len(magic):]
+	b, d.seed1 = consumeUint64(b)
+	b, d.seed2 = consumeUint64(b)
+	b, d.h1 = consumeUint64(b)
+	b, d.h2 = consumeUint64(b)
+	d.bmixer = d
+	d.consumeState(b)
+	return nil
+}
+
 func (d *digest128) bmix(p []byte) (tail []byte) {
 	h1, h2 := d.h1, d.h2
-
 	for len(p) >= 16 {
 		k1 := uint64(p[0]) | uint64(p[1])<<8 | uint64(p[2])<<16 | uint64(p[3])<<24 | uint64(p[4])<<32 | uint64(p[5])<<40 | uint64(p[6])<<48 | uint64(p[7])<<56
 		k2 := uint64(p[8]) | uint64(p[9])<<8 | uint64(p[10])<<16 | uint64(p[11])<<24 | uint64(p[12])<<32 | uint64(p[13])<<40 | uint64(p[14])<<48 | uint64(p[15])<<56
 		p = p[16:]
+		h1, h2 = Mix128Block(h1, h2, k1, k2)
+	}
+	d.h1, d.h2 = h1, h2
+	return p
+}
 
-		k1 *= c1_128
-		k1 = bits.RotateLeft64(k1, 31)
-		k1 *= c2_128
-		h1 ^= k1
-
-		h1 = bits.RotateLeft64(h1, 27)
-		h1 += h2
-		h1 = h1*5 + 0x52dce729
-
-		k2 *= c2_128
-		k2 = bits.RotateLeft64(k2, 33)
-		k2 *= c1_128
-		h2 ^= k2
-
-		h2 = bits.RotateLeft64(h2, 31)
-		h2 += h1
-		h2 = h2*5 + 0x38495ab5
+func (d *digest128) bmixString(p string) (tail string) {
+	h1, h2 := d.h1, d.h2
+	for len(p) >= 16 {
+		k1 := uint64(p[0]) | uint64(p[1])<<8 | uint64(p[2])<<16 | uint64(p[3])<<24 | uint64(p[4])<<32 | uint64(p[5])<<40 | uint64(p[6])<<48 | uint64(p[7])<<56
+		k2 := uint64(p[8]) | uint64(p[9])<<8 | uint64(p[10])<<16 | uint64(p[11])<<24 | uint64(p[12])<<32 | uint64(p[13])<<40 | uint64(p[14])<<48 | uint64(p[15])<<56
+		p = p[16:]
+		h1, h2 = Mix128Block(h1, h2, k1, k2)
 	}
 	d.h1, d.h2 = h1, h2
 	return p
@@ -163,20 +204,11 @@ func (d *digest128) Sum128() (h1, h2 uint64) {
 	h1 += h2
 	h2 += h1
 
-	h1 = fmix64(h1)
-	h2 = fmix64(h2)
+	h1 = Fmix64(h1)
+	h2 = Fmix64(h2)
 
 	h1 += h2
 	h2 += h1
 
 	return h1, h2
 }
-
-func fmix64(k uint64) uint64 {
-	k ^= k >> 33
-	k *= 0xff51afd7ed558ccd
-	k ^= k >> 33
-	k *= 0xc4ceb9fe1a85ec53
-	k ^= k >> 33
-	return k
-}
@@ -1,5 +1,7 @@
-//go:build go1.5 && amd64 && !gccgo
-// +build go1.5,amd64,!gccgo
+//go:build go1.5 && amd64 && !gccgo && !purego
+// +build go1.5,amd64,!gccgo,!purego
+
+#include "textflag.h"
 
 // SeedSum128(seed1, seed2 uint64, data []byte) (h1 uint64, h2 uint64)
 TEXT ·SeedSum128(SB), $0-56
@@ -8,6 +10,7 @@ TEXT ·SeedSum128(SB), $0-56
 	MOVQ data_base+16(FP), SI
 	MOVQ data_len+24(FP), R9
 	LEAQ h1+40(FP), BX
+	XORQ R10, R10
 	JMP  sum128internal<>(SB)
 
 // Sum128(data []byte) (h1 uint64, h2 uint64)
@@ -17,6 +20,7 @@ TEXT ·Sum128(SB), $0-40
 	MOVQ data_base+0(FP), SI
 	MOVQ data_len+8(FP), R9
 	LEAQ h1+24(FP), BX
+	XORQ R10, R10
 	JMP  sum128internal<>(SB)
 
 // SeedStringSum128(seed1, seed2 uint64, data string) (h1 uint64, h2 uint64)
@@ -26,6 +30,7 @@ TEXT ·SeedStringSum128(SB), $0-48
 	MOVQ data_base+16(FP), SI
 	MOVQ data_len+24(FP), R9
 	LEAQ h1+32(FP), BX
+	XORQ R10, R10
 	JMP  sum128internal<>(SB)
 
 // StringSum128(data string) (h1 uint64, h2 uint64)
@@ -35,6 +40,7 @@ TEXT ·StringSum128(SB), $0-32
 	MOVQ data_base+0(FP), SI
 	MOVQ data_len+8(FP), R9
 	LEAQ h1+16(FP), BX
+	XORQ R10, R10
 	JMP  sum128internal<>(SB)
 
 // Expects:
@@ -42,7 +48,10 @@ TEXT ·StringSum128(SB), $0-32
 // R13 == h2 uint64 seed
 // SI  == &data
 // R9  == len(data)
+// R10 == offset of the first unmixed block, normally 0
 // BX  == &[2]uint64 return
+//
+// DI, R8 and R11 are left untouched.
 TEXT sum128internal<>(SB), $0
 	MOVQ $0x87c37b91114253d5, R14 // c1
 	MOVQ $0x4cf5ad432745937f, R15 // c2
@@ -50,9 +59,7 @@ TEXT sum128internal<>(SB), $0
 	MOVQ R9, CX
 	ANDQ $-16, CX // cx == data_len - (data_len % 16)
 
-	// for r10 = 0; r10 < cx; r10 += 16 {...
-	XORQ R10, R10
-
+	// for ; r10 < cx; r10 += 16 {...
 loop:
 	CMPQ R10, CX
 	JE   tail
@@ -245,3 +252,198 @@ finalize:
 	MOVQ R12, (BX)
 	MOVQ R13, 8(BX)
 	RET
+
+// sum128Batch(seed1, seed2 uint64, keys [][]byte, out [][2]uint64)
+TEXT ·sum128Batch(SB), NOSPLIT, $24-64
+	MOVQ keys_base+16(FP), AX
+	MOVQ AX, 0(SP) // &keys[i]
+	MOVQ keys_len+24(FP), AX
+	MOVQ AX, 8(SP) // keys remaining
+	MOVQ out_base+40(FP), AX
+	MOVQ AX, 16(SP) // &out[i]
+
+pairs:
+	CMPQ 8(SP), $2
+	JLT  single
+
+	MOVQ 0(SP), BX
+	MOVQ 0(BX), SI
+	MOVQ 24(BX), DI
+	MOVQ 8(BX), CX
+	MOVQ 32(BX), AX
+	CMPQ AX, CX
+	CMOVQLT AX, CX
+	ANDQ $-16, CX
+	MOVQ seed1+0(FP), R12
+	MOVQ seed2+8(FP), R13
+	MOVQ R12, R8
+	MOVQ R13, R9
+	CALL sum128x2blocks<>(SB)
+
+	// Park the second key's running hash in its output while the first
+	// finishes; R11 survives sum128internal.
+	MOVQ 16(SP), BX
+	MOVQ R8, 16(BX)
+	MOVQ R9, 24(BX)
+	MOVQ R10, R11
+	MOVQ 0(SP), AX
+	MOVQ 8(AX), R9
+	CALL sum128internal<>(SB)
+
+	MOVQ 16(SP), BX
+	ADDQ $16, BX
+	MOVQ (BX), R12
+	MOVQ 8(BX), R13
+	MOVQ 0(SP), AX
+	MOVQ 24(AX), SI
+	MOVQ 32(AX), R9
+	MOVQ R11, R10
+	CALL sum128internal<>(SB)
+
+	ADDQ $48, 0(SP)
+	SUBQ $2, 8(SP)
+	ADDQ $32, 16(SP)
+	JMP  pairs
+
+single:
+	CMPQ 8(SP), $0
+	JE   done
+	MOVQ 0(SP), AX
+	MOVQ 0(AX), SI
+	MOVQ 8(AX), R9
+	MOVQ seed1+0(FP), R12
+	MOVQ seed2+8(FP), R13
+	MOVQ 16(SP), BX
+	XORQ R10, R10
+	CALL sum128internal<>(SB)
+
+done:
+	RET
+
+// stringSum128Batch(seed1, seed2 uint64, keys []string, out [][2]uint64)
+TEXT ·stringSum128Batch(SB), NOSPLIT, $24-64
+	MOVQ keys_base+16(FP), AX
+	MOVQ AX, 0(SP) // &keys[i]
+	MOVQ keys_len+24(FP), AX
+	MOVQ AX, 8(SP) // keys remaining
+	MOVQ out_base+40(FP), AX
+	MOVQ AX, 16(SP) // &out[i]
+
+pairs:
+	CMPQ 8(SP), $2
+	JLT  single
+
+	MOVQ 0(SP), BX
+	MOVQ 0(BX), SI
+	MOVQ 16(BX), DI
+	MOVQ 8(BX), CX
+	MOVQ 24(BX), AX
+	CMPQ AX, CX
+	CMOVQLT AX, CX
+	ANDQ $-16, CX
+	MOVQ seed1+0(FP), R12
+	MOVQ seed2+8(FP), R13
+	MOVQ R12, R8
+	MOVQ R13, R9
+	CALL sum128x2blocks<>(SB)
+
+	MOVQ 16(SP), BX
+	MOVQ R8, 16(BX)
+	MOVQ R9, 24(BX)
+	MOVQ R10, R11
+	MOVQ 0(SP), AX
+	MOVQ 8(AX), R9
+	CALL sum128internal<>(SB)
+
+	MOVQ 16(SP), BX
+	ADDQ $16, BX
+	MOVQ (BX), R12
+	MOVQ 8(BX), R13
+	MOVQ 0(SP), AX
+	MOVQ 16(AX), SI
+	MOVQ 24(AX), R9
+	MOVQ R11, R10
+	CALL sum128internal<>(SB)
+
+	ADDQ $32, 0(SP)
+	SUBQ $2, 8(SP)
+	ADDQ $32, 16(SP)
+	JMP  pairs
+
+single:
+	CMPQ 8(SP), $0
+	JE   done
+	MOVQ 0(SP), AX
+	MOVQ 0(AX), SI
+	MOVQ 8(AX), R9
+	MOVQ seed1+0(FP), R12
+	MOVQ seed2+8(FP), R13
+	MOVQ 16(SP), BX
+	XORQ R10, R10
+	CALL sum128internal<>(SB)
+
+done:
+	RET
+
+// Mixes the blocks two keys have in common, interleaving the two independent
+// hashes so that one's multiplies hide the other's latency.
+//
+// Expects:
+// R12, R13 == a's running h1, h2
+// R8,  R9  == b's running h1, h2
+// SI       == &a
+// DI       == &b
+// CX       == min(len(a), len(b)) rounded down to a multiple of 16
+//
+// Returns with R10 == CX; clobbers AX, DX, BX, R11, R14, R15.
+TEXT sum128x2blocks<>(SB), NOSPLIT, $0
+	MOVQ $0x87c37b91114253d5, R14 // c1
+	MOVQ $0x4cf5ad432745937f, R15 // c2
+	XORQ R10, R10
+
+loop:
+	CMPQ R10, CX
+	JE   done
+	MOVQ (SI)(R10*1), AX
+	MOVQ 8(SI)(R10*1), DX
+	MOVQ (DI)(R10*1), BX
+	MOVQ 8(DI)(R10*1), R11
+	ADDQ $16, R10
+
+	IMULQ R14, AX
+	IMULQ R15, DX
+	IMULQ R14, BX
+	IMULQ R15, R11
+
+	ROLQ $31, AX
+	ROLQ $33, DX
+	ROLQ $31, BX
+	ROLQ $33, R11
+
+	IMULQ R15, AX
+	IMULQ R14, DX
+	IMULQ R15, BX
+	IMULQ R14, R11
+
+	XORQ AX, R12
+	XORQ BX, R8
+	ROLQ $27, R12
+	ROLQ $27, R8
+	ADDQ R13, R12
+	ADDQ R9, R8
+	XORQ DX, R13
+	XORQ R11, R9
+	ROLQ $31, R13
+	ROLQ $31, R9
+	LEAQ 0x52dce729(R12)(R12*4), R12
+	LEAQ 0x52dce729(R8)(R8*4), R8
+
+	ADDQ R12, R13
+	ADDQ R8, R9
+	LEAQ 0x38495ab5(R13)(R13*4), R13
+	LEAQ 0x38495ab5(R9)(R9*4), R9
+
+	JMP loop
+
+done:
+	RET
@@ -0,0 +1,178 @@
+//go:build go1.5 && arm64 && murmur3_arm64asm && !gccgo && !purego
+// +build go1.5,arm64,murmur3_arm64asm,!gccgo,!purego
+
+#include "textflag.h"
+
+// SeedSum128(seed1, seed2 uint64, data []byte) (h1 uint64, h2 uint64)
+TEXT ·SeedSum128(SB), NOSPLIT|NOFRAME, $0-56
+	MOVD seed1+0(FP), R0
+	MOVD seed2+8(FP), R1
+	MOVD data_base+16(FP), R2
+	MOVD data_len+24(FP), R3
+	MOVD $h1+40(FP), R4
+	B    sum128internal<>(SB)
+
+// Sum128(data []byte) (h1 uint64, h2 uint64)
+TEXT ·Sum128(SB), NOSPLIT|NOFRAME, $0-40
+	MOVD ZR, R0
+	MOVD ZR, R1
+	MOVD data_base+0(FP), R2
+	MOVD data_len+8(FP), R3
+	MOVD $h1+24(FP), R4
+	B    sum128internal<>(SB)
+
+// SeedStringSum128(seed1, seed2 uint64, data string) (h1 uint64, h2 uint64)
+TEXT ·SeedStringSum128(SB), NOSPLIT|NOFRAME, $0-48
+	MOVD seed1+0(FP), R0
+	MOVD seed2+8(FP), R1
+	MOVD data_base+16(FP), R2
+	MOVD data_len+24(FP), R3
+	MOVD $h1+32(FP), R4
+	B    sum128internal<>(SB)
+
+// StringSum128(data string) (h1 uint64, h2 uint64)
+TEXT ·StringSum128(SB), NOSPLIT|NOFRAME, $0-32
+	MOVD ZR, R0
+	MOVD ZR, R1
+	MOVD data_base+0(FP), R2
+	MOVD data_len+8(FP), R3
+	MOVD $h1+16(FP), R4
+	B    sum128internal<>(SB)
+
+// Expects:
+// R0 == h1 uint64 seed
+// R1 == h2 uint64 seed
+// R2 == &data
+// R3 == len(data)
+// R4 == &[2]uint64 return
+TEXT sum128internal<>(SB), NOSPLIT|NOFRAME, $0
+	MOVD $0x87c37b91114253d5, R5 // c1
+	MOVD $0x4cf5ad432745937f, R6 // c2
+	MOVD $0x52dce729, R10
+	MOVD $0x38495ab5, R11
+
+	// R7 == bytes remaining; R2 advances through data.
+	MOVD R3, R7
+
+loop:
+	CMP   $16, R7
+	BLT   tail
+	LDP.P 16(R2), (R8, R9)
+	SUB   $16, R7, R7
+
+	MUL R5, R8, R8
+	MUL R6, R9, R9
+
+	ROR $33, R8, R8 // rotl 31
+	ROR $31, R9, R9 // rotl 33
+
+	MUL R6, R8, R8
+	MUL R5, R9, R9
+
+	EOR R8, R0, R0
+	ROR $37, R0, R0 // rotl 27
+	ADD R1, R0, R0
+	EOR R9, R1, R1
+	ROR $33, R1, R1 // rotl 31
+	ADD R0<<2, R0, R0
+	ADD R10, R0, R0
+
+	ADD R0, R1, R1
+	ADD R1<<2, R1, R1
+	ADD R11, R1, R1
+
+	B loop
+
+tail:
+	CBZ R7, finalize
+
+	// Rather than a jump table, the tail is assembled with overlapping
+	// loads that never read outside of data[len(data)-R7:len(data)].
+	//
+	// R12 == &data[len(data)]
+	ADD R7, R2, R12
+	CMP $8, R7
+	BLT tailunder8
+	BEQ tail8
+
+	// 9 to 15 bytes: the high bytes of the last eight form k2.
+	MOVD  -8(R12), R9
+	MOVD  $16, R13
+	SUB   R7, R13, R13
+	LSL   $3, R13, R13
+	LSR   R13, R9, R9
+	MUL   R6, R9, R9
+	ROR   $31, R9, R9 // rotl 33
+	MUL   R5, R9, R9
+	EOR   R9, R1, R1
+
+tail8:
+	MOVD (R2), R8
+	B    fintaillow
+
+tailunder8:
+	CMP $4, R7
+	BLT tailunder4
+
+	// 4 to 7 bytes: the low four bytes plus the high bytes of the last four.
+	MOVWU (R2), R8
+	MOVWU -4(R12), R9
+	MOVD  $8, R13
+	SUB   R7, R13, R13
+	LSL   $3, R13, R13
+	LSR   R13, R9, R9
+	ORR   R9<<32, R8, R8
+	B     fintaillow
+
+tailunder4:
+	// 1 to 3 bytes: data[0], data[len/2] and data[len-1] cover all bytes,
+	// with duplicates landing in the same position.
+	MOVBU (R2), R8
+	LSR   $1, R7, R13
+	MOVBU (R2)(R13), R9
+	LSL   $3, R13, R13
+	LSL   R13, R9, R9
+	ORR   R9, R8, R8
+	MOVBU -1(R12), R9
+	SUB   $1, R7, R13
+	LSL   $3, R13, R13
+	LSL   R13, R9, R9
+	ORR   R9, R8, R8
+
+fintaillow:
+	MUL R5, R8, R8
+	ROR $33, R8, R8 // rotl 31
+	MUL R6, R8, R8
+	EOR R8, R0, R0
+
+finalize:
+	EOR R3, R0, R0
+	EOR R3, R1, R1
+
+	ADD R1, R0, R0
+	ADD R0, R1, R1
+
+	// fmix128 (both interleaved)
+	MOVD $0xff51afd7ed558ccd, R5
+	MOVD $0xc4ceb9fe1a85ec53, R6
+
+	EOR R0>>33, R0, R0
+	EOR R1>>33, R1, R1
+
+	MUL R5, R0, R0
+	MUL R5, R1, R1
+
+	EOR R0>>33, R0, R0
+	EOR R1>>33, R1, R1
+
+	MUL R6, R0, R0
+	MUL R6, R1, R1
+
+	EOR R0>>33, R0, R0
+	EOR R1>>33, R1, R1
+
+	ADD R1, R0, R0
+	ADD R0, R1, R1
+
+	STP (R0, R1), (R4)
+	RET
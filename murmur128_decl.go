@@ -1,5 +1,8 @@
-//go:build go1.5 && amd64 && !gccgo
-// +build go1.5,amd64,!gccgo
+//go:build go1.5 && (amd64 || (arm64 && murmur3_arm64asm)) && !gccgo && !purego
+// +build go1.5
+// +build amd64 arm64,murmur3_arm64asm
+// +build !gccgo
+// +build !purego
 
 package murmur3
 
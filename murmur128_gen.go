@@ -1,5 +1,5 @@
-//go:build !go1.5 || !amd64 || gccgo
-// +build !go1.5 !amd64 gccgo
+//go:build !go1.5 || (!amd64 && !arm64) || (!amd64 && !murmur3_arm64asm) || gccgo || purego
+// +build !go1.5 !amd64,!arm64 !amd64,!murmur3_arm64asm gccgo purego
 
 package murmur3
 
@@ -117,8 +117,8 @@ func SeedSum128(seed1, seed2 uint64, data []byte) (h1 uint64, h2 uint64) {
 	h1 += h2
 	h2 += h1
 
-	h1 = fmix64(h1)
-	h2 = fmix64(h2)
+	h1 = Fmix64(h1)
+	h2 = Fmix64(h2)
 
 	h1 += h2
 	h2 += h1
@@ -226,8 +226,8 @@ func SeedStringSum128(seed1, seed2 uint64, data string) (h1 uint64, h2 uint64) {
 	h1 += h2
 	h2 += h1
 
-	h1 = fmix64(h1)
-	h2 = fmix64(h2)
+	h1 = Fmix64(h1)
+	h2 = Fmix64(h2)
 
 	h1 += h2
 	h2 += h1
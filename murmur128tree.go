@@ -0,0 +1,198 @@
+package murmur3
+
+import (
+	"runtime"
+	"sync"
+)
+
+// Make sure interfaces are correctly implemented.
+var _ Hash128 = new(treeDigest128)
+
+// DefaultTreeChunkSize is the chunk size TreeSum128 and NewTreeHash128 use
+// when given a chunk size that is not positive.
+const DefaultTreeChunkSize = 1 << 20
+
+// TreeSum128 returns a tree mode hash of data, computed by splitting data into
+// chunkSize byte chunks, hashing the chunks concurrently on up to workers
+// goroutines, and hashing the chunk sums together.
+//
+// Tree mode is not murmur3: its sums never equal Sum128 of the same data, and
+// they differ for different chunk sizes. They do not depend on the number of
+// workers, though, nor on how data is written to a NewTreeHash128.
+//
+// Specifically, each chunk is hashed with SeedSum128(0, 0, chunk), a trailing
+// partial chunk included and empty data being one empty chunk, and the sum is
+// SeedSum128(chunkSize, chunkSize, sums), where sums are the chunk sums in
+// order, each as the little endian bytes of h1 and then h2.
+//
+// A chunkSize that is not positive means DefaultTreeChunkSize, and workers
+// that is not positive means runtime.GOMAXPROCS(0). Workers is lowered so
+// that chunkSize*workers fits in an int.
+func TreeSum128(data []byte, chunkSize, workers int) (h1 uint64, h2 uint64) {
+	chunkSize, workers = treeParams(chunkSize, workers)
+	leaves := make([]byte, 16*numLeaves(len(data), chunkSize))
+	hashLeaves(data, chunkSize, workers, leaves)
+	return SeedSum128(uint64(chunkSize), uint64(chunkSize), leaves)
+}
+
+func treeParams(chunkSize, workers int) (int, int) {
+	if chunkSize <= 0 {
+		chunkSize = DefaultTreeChunkSize
+	}
+	if workers <= 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+	if workers > maxInt/chunkSize {
+		workers = maxInt / chunkSize
+	}
+	return chunkSize, workers
+}
+
+// numLeaves returns the number of chunks in n bytes, with no bytes being one
+// empty chunk.
+func numLeaves(n, chunkSize int) int {
+	nleaves := n / chunkSize
+	if n%chunkSize != 0 || n == 0 {
+		nleaves++
+	}
+	return nleaves
+}
+
+// hashLeaves writes the sum of every chunk of data into leaves, which must
+// hold 16 bytes per chunk.
+func hashLeaves(data []byte, chunkSize, workers int, leaves []byte) {
+	nleaves := len(leaves) / 16
+	leaf := func(i int) {
+		chunk := data[i*chunkSize:]
+		if len(chunk) > chunkSize {
+			chunk = chunk[:chunkSize]
+		}
+		h1, h2 := SeedSum128(0, 0, chunk)
+		appendUint64LE(appendUint64LE(leaves[16*i:16*i], h1), h2)
+	}
+
+	if workers > nleaves {
+		workers = nleaves
+	}
+	if workers <= 1 {
+		for i := 0; i < nleaves; i++ {
+			leaf(i)
+		}
+		return
+	}
+	var wg sync.WaitGroup
+	wg.Add(workers)
+	for w := 0; w < workers; w++ {
+		go func(w int) {
+			defer wg.Done()
+			for i := w; i < nleaves; i += workers {
+				leaf(i)
+			}
+		}(w)
+	}
+	wg.Wait()
+}
+
+// treeDigest128 buffers up to workers chunks at a time, hashing them together
+// once the buffer fills and streaming their sums into the root digest.
+type treeDigest128 struct {
+	chunkSize int
+	workers   int
+
+	root   *digest128
+	buf    []byte // pending data, less than workers chunks
+	leaves []byte // scratch for chunk sums
+	clen   int
+}
+
+// NewTreeHash128 returns a Hash128 computing TreeSum128 of everything written
+// to it. It buffers up to workers chunks, hashing them concurrently whenever
+// the buffer fills; writes of whole buffers are hashed without copying.
+//
+// The buffer is allocated as data is written, so hashing less than
+// chunkSize*workers bytes costs only what was written, but a long stream of
+// small writes holds up to chunkSize*workers bytes until the hash is
+// discarded.
+func NewTreeHash128(chunkSize, workers int) Hash128 {
+	chunkSize, workers = treeParams(chunkSize, workers)
+	t := &treeDigest128{
+		chunkSize: chunkSize,
+		workers:   workers,
+	}
+	t.Reset()
+	return t
+}
+
+func (t *treeDigest128) Size() int { return 16 }
+
+// BlockSize returns the chunk size.
+func (t *treeDigest128) BlockSize() int { return t.chunkSize }
+
+func (t *treeDigest128) Reset() {
+	t.root = SeedNew128(uint64(t.chunkSize), uint64(t.chunkSize)).(*digest128)
+	t.buf = t.buf[:0]
+	t.clen = 0
+}
+
+// hashChunks streams the sums of the whole chunks of data into the root.
+func (t *treeDigest128) hashChunks(data []byte) {
+	n := 16 * (len(data) / t.chunkSize)
+	if cap(t.leaves) < n {
+		t.leaves = make([]byte, n)
+	}
+	hashLeaves(data, t.chunkSize, t.workers, t.leaves[:n])
+	t.root.Write(t.leaves[:n])
+}
+
+func (t *treeDigest128) Write(p []byte) (n int, err error) {
+	n = len(p)
+	t.clen += n
+	full := t.chunkSize * t.workers
+	for len(p) > 0 {
+		if len(t.buf) == 0 && len(p) >= full {
+			whole := len(p) - len(p)%t.chunkSize
+			t.hashChunks(p[:whole])
+			p = p[whole:]
+			continue
+		}
+		take := full - len(t.buf)
+		if take > len(p) {
+			take = len(p)
+		}
+		t.buf = append(t.buf, p[:take]...)
+		p = p[take:]
+		if len(t.buf) == full {
+			t.hashChunks(t.buf)
+			t.buf = t.buf[:0]
+		}
+	}
+	return n, nil
+}
+
+func (t *treeDigest128) Sum(b []byte) []byte {
+	h1, h2 := t.Sum128()
+	return append(b,
+		byte(h1>>56), byte(h1>>48), byte(h1>>40), byte(h1>>32),
+		byte(h1>>24), byte(h1>>16), byte(h1>>8), byte(h1),
+
+		byte(h2>>56), byte(h2>>48), byte(h2>>40), byte(h2>>32),
+		byte(h2>>24), byte(h2>>16), byte(h2>>8), byte(h2),
+	)
+}
+
+func (t *treeDigest128) Sum128() (h1, h2 uint64) {
+	pending := t.buf
+	nleaves := 0
+	if len(pending) > 0 || t.clen == 0 {
+		nleaves = numLeaves(len(pending), t.chunkSize)
+	}
+	leaves := make([]byte, 16*nleaves)
+	hashLeaves(pending, t.chunkSize, t.workers, leaves)
+
+	// Finish on a copy of the root so that writes can continue.
+	root := *t.root
+	root.bmixer = &root
+	root.tail = root.buf[:len(t.root.tail)]
+	root.Write(leaves)
+	return root.Sum128()
+}
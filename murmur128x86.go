@@ -0,0 +1,250 @@
+package murmur3
+
+import (
+	"encoding"
+	"hash"
+	"io"
+	"math/bits"
+)
+
+const (
+	c1_128x86 uint32 = 0x239b961b
+	c2_128x86 uint32 = 0xab0e9789
+	c3_128x86 uint32 = 0x38b34ae5
+	c4_128x86 uint32 = 0xa1e38b93
+)
+
+// Make sure interfaces are correctly implemented.
+var (
+	_ hash.Hash = new(digest128x86)
+	_ Hash128   = new(digest128x86)
+	_ bmixer    = new(digest128x86)
+
+	_ encoding.BinaryMarshaler   = new(digest128x86)
+	_ encoding.BinaryUnmarshaler = new(digest128x86)
+
+	_ io.ReaderFrom = new(digest128x86)
+	_ stringWriter  = new(digest128x86)
+)
+
+// digest128x86 represents a partial evaluation of a 128 bites hash using the
+// x86 variant, which runs four 32 bit lanes rather than two 64 bit lanes.
+type digest128x86 struct {
+	digest
+	seed uint32
+	h1   uint32 // Unfinalized running hash part 1.
+	h2   uint32 // Unfinalized running hash part 2.
+	h3   uint32 // Unfinalized running hash part 3.
+	h4   uint32 // Unfinalized running hash part 4.
+}
+
+// SeedNew128x86 returns a Hash128 for streaming MurmurHash3_x86_128 sums with
+// all four of its internal digests initialized to seed.
+//
+// The x86 variant produces different sums than the x64 variant used by
+// New128. Sum128 packs the four 32 bit lanes as h2<<32|h1 and h4<<32|h3,
+// which matches reading the canonical 16 byte output as two little endian
+// uint64s.
+//
+// The returned hash also implements encoding.BinaryMarshaler and
+// encoding.BinaryUnmarshaler to marshal and unmarshal its internal state.
+func SeedNew128x86(seed uint32) Hash128 {
+	d := &digest128x86{seed: seed}
+	d.bmixer = d
+	d.Reset()
+	return d
+}
+
+// New128x86 returns a Hash128 for streaming MurmurHash3_x86_128 sums.
+func New128x86() Hash128 {
+	return SeedNew128x86(0)
+}
+
+func (d *digest128x86) Size() int { return 16 }
+
+func (d *digest128x86) reset() { d.h1, d.h2, d.h3, d.h4 = d.seed, d.seed, d.seed, d.seed }
+
+func (d *digest128x86) Sum(b []byte) []byte {
+	h1, h2 := d.Sum128()
+	return append(b,
+		byte(h1>>56), byte(h1>>48), byte(h1>>40), byte(h1>>32),
+		byte(h1>>24), byte(h1>>16), byte(h1>>8), byte(h1),
+
+		byte(h2>>56), byte(h2>>48), byte(h2>>40), byte(h2>>32),
+		byte(h2>>24), byte(h2>>16), byte(h2>>8), byte(h2),
+	)
+}
+
+// marshaledSize128x86 is the magic, seed, h1 through h4, clen and a full
+// block.
+const marshaledSize128x86 = len(magic128x86) + 4 + 4*4 + 8 + 16
+
+func (d *digest128x86) MarshalBinary() ([]byte, error) {
+	b := make([]byte, 0, marshaledSize128x86)
+	b = append(b, magic128x86...)
+	b = appendUint32(b, d.seed)
+	b = appendUint32(b, d.h1)
+	b = appendUint32(b, d.h2)
+	b = appendUint32(b, d.h3)
+	b = appendUint32(b, d.h4)
+	b = d.appendState(b)
+	return b, nil
+}
+
+func (d *digest128x86) UnmarshalBinary(b []byte) error {
+	if len(b) < len(magic128x86) || string(b[:len(magic128x86)]) != magic128x86 {
+		return errStateIdentifier
+	}
+	if len(b) != marshaledSize128x86 || !validState(b[len(b)-8-d.Size():], d.Size()) {
+		return errStateSize
+	}
+	b = b[len(magic128x86):]
+	b, d.seed = consumeUint32(b)
+	b, d.h1 = consumeUint32(b)
+	b, d.h2 = consumeUint32(b)
+	b, d.h3 = consumeUint32(b)
+	b, d.h4 = consumeUint32(b)
+	d.bmixer = d
+	d.consumeState(b)
+	return nil
+}
+
+// mix128x86Lane returns lane h of the x86_128 state after mixing in its
+// block k, given the next lane's current value. The constants differ per lane;
+// see bmix.
+func mix128x86Lane(h, next, k, ca, cb uint32, kr, hr int, add uint32) uint32 {
+	k *= ca
+	k = bits.RotateLeft32(k, kr)
+	k *= cb
+	h ^= k
+	h = bits.RotateLeft32(h, hr)
+	h += next
+	return h*5 + add
+}
+
+func (d *digest128x86) bmix(p []byte) (tail []byte) {
+	h1, h2, h3, h4 := d.h1, d.h2, d.h3, d.h4
+	for len(p) >= 16 {
+		k1 := uint32(p[0]) | uint32(p[1])<<8 | uint32(p[2])<<16 | uint32(p[3])<<24
+		k2 := uint32(p[4]) | uint32(p[5])<<8 | uint32(p[6])<<16 | uint32(p[7])<<24
+		k3 := uint32(p[8]) | uint32(p[9])<<8 | uint32(p[10])<<16 | uint32(p[11])<<24
+		k4 := uint32(p[12]) | uint32(p[13])<<8 | uint32(p[14])<<16 | uint32(p[15])<<24
+		p = p[16:]
+		h1 = mix128x86Lane(h1, h2, k1, c1_128x86, c2_128x86, 15, 19, 0x561ccd1b)
+		h2 = mix128x86Lane(h2, h3, k2, c2_128x86, c3_128x86, 16, 17, 0x0bcaa747)
+		h3 = mix128x86Lane(h3, h4, k3, c3_128x86, c4_128x86, 17, 15, 0x96cd1c35)
+		h4 = mix128x86Lane(h4, h1, k4, c4_128x86, c1_128x86, 18, 13, 0x32ac3b17)
+	}
+	d.h1, d.h2, d.h3, d.h4 = h1, h2, h3, h4
+	return p
+}
+
+func (d *digest128x86) bmixString(p string) (tail string) {
+	h1, h2, h3, h4 := d.h1, d.h2, d.h3, d.h4
+	for len(p) >= 16 {
+		k1 := uint32(p[0]) | uint32(p[1])<<8 | uint32(p[2])<<16 | uint32(p[3])<<24
+		k2 := uint32(p[4]) | uint32(p[5])<<8 | uint32(p[6])<<16 | uint32(p[7])<<24
+		k3 := uint32(p[8]) | uint32(p[9])<<8 | uint32(p[10])<<16 | uint32(p[11])<<24
+		k4 := uint32(p[12]) | uint32(p[13])<<8 | uint32(p[14])<<16 | uint32(p[15])<<24
+		p = p[16:]
+		h1 = mix128x86Lane(h1, h2, k1, c1_128x86, c2_128x86, 15, 19, 0x561ccd1b)
+		h2 = mix128x86Lane(h2, h3, k2, c2_128x86, c3_128x86, 16, 17, 0x0bcaa747)
+		h3 = mix128x86Lane(h3, h4, k3, c3_128x86, c4_128x86, 17, 15, 0x96cd1c35)
+		h4 = mix128x86Lane(h4, h1, k4, c4_128x86, c1_128x86, 18, 13, 0x32ac3b17)
+	}
+	d.h1, d.h2, d.h3, d.h4 = h1, h2, h3, h4
+	return p
+}
+
+func (d *digest128x86) Sum128() (uint64, uint64) {
+	h1, h2, h3, h4 := d.h1, d.h2, d.h3, d.h4
+
+	var k1, k2, k3, k4 uint32
+	switch len(d.tail) & 15 {
+	case 15:
+		k4 ^= uint32(d.tail[14]) << 16
+		fallthrough
+	case 14:
+		k4 ^= uint32(d.tail[13]) << 8
+		fallthrough
+	case 13:
+		k4 ^= uint32(d.tail[12]) << 0
+		k4 *= c4_128x86
+		k4 = bits.RotateLeft32(k4, 18)
+		k4 *= c1_128x86
+		h4 ^= k4
+		fallthrough
+
+	case 12:
+		k3 ^= uint32(d.tail[11]) << 24
+		fallthrough
+	case 11:
+		k3 ^= uint32(d.tail[10]) << 16
+		fallthrough
+	case 10:
+		k3 ^= uint32(d.tail[9]) << 8
+		fallthrough
+	case 9:
+		k3 ^= uint32(d.tail[8]) << 0
+		k3 *= c3_128x86
+		k3 = bits.RotateLeft32(k3, 17)
+		k3 *= c4_128x86
+		h3 ^= k3
+		fallthrough
+
+	case 8:
+		k2 ^= uint32(d.tail[7]) << 24
+		fallthrough
+	case 7:
+		k2 ^= uint32(d.tail[6]) << 16
+		fallthrough
+	case 6:
+		k2 ^= uint32(d.tail[5]) << 8
+		fallthrough
+	case 5:
+		k2 ^= uint32(d.tail[4]) << 0
+		k2 *= c2_128x86
+		k2 = bits.RotateLeft32(k2, 16)
+		k2 *= c3_128x86
+		h2 ^= k2
+		fallthrough
+
+	case 4:
+		k1 ^= uint32(d.tail[3]) << 24
+		fallthrough
+	case 3:
+		k1 ^= uint32(d.tail[2]) << 16
+		fallthrough
+	case 2:
+		k1 ^= uint32(d.tail[1]) << 8
+		fallthrough
+	case 1:
+		k1 ^= uint32(d.tail[0]) << 0
+		k1 *= c1_128x86
+		k1 = bits.RotateLeft32(k1, 15)
+		k1 *= c2_128x86
+		h1 ^= k1
+	}
+
+	h1 ^= uint32(d.clen)
+	h2 ^= uint32(d.clen)
+	h3 ^= uint32(d.clen)
+	h4 ^= uint32(d.clen)
+
+	h1 += h2 + h3 + h4
+	h2 += h1
+	h3 += h1
+	h4 += h1
+
+	h1 = Fmix32(h1)
+	h2 = Fmix32(h2)
+	h3 = Fmix32(h3)
+	h4 = Fmix32(h4)
+
+	h1 += h2 + h3 + h4
+	h2 += h1
+	h3 += h1
+	h4 += h1
+
+	return uint64(h2)<<32 | uint64(h1), uint64(h4)<<32 | uint64(h3)
+}
@@ -0,0 +1,297 @@
+package murmur3
+
+import "math/bits"
+
+// Sum128x86 returns the MurmurHash3_x86_128 sum of data. It is equivalent to
+// the following sequence (without the extra burden and the extra allocation):
+//
+//	hasher := New128x86()
+//	hasher.Write(data)
+//	return hasher.Sum128()
+func Sum128x86(data []byte) (uint64, uint64) {
+	return SeedSum128x86(0, data)
+}
+
+// SeedSum128x86 returns the MurmurHash3_x86_128 sum of data with all four
+// digests initialized to seed. See SeedNew128x86 for how the four 32 bit lanes
+// are packed into h1 and h2.
+//
+// This reads and processes the data in chunks of little endian uint32s;
+// thus, the returned hashes are portable across architectures.
+func SeedSum128x86(seed uint32, data []byte) (uint64, uint64) {
+	h1, h2, h3, h4 := seed, seed, seed, seed
+	clen := len(data)
+	for len(data) >= 16 {
+		k1 := uint32(data[0]) | uint32(data[1])<<8 | uint32(data[2])<<16 | uint32(data[3])<<24
+		k2 := uint32(data[4]) | uint32(data[5])<<8 | uint32(data[6])<<16 | uint32(data[7])<<24
+		k3 := uint32(data[8]) | uint32(data[9])<<8 | uint32(data[10])<<16 | uint32(data[11])<<24
+		k4 := uint32(data[12]) | uint32(data[13])<<8 | uint32(data[14])<<16 | uint32(data[15])<<24
+		data = data[16:]
+
+		k1 *= c1_128x86
+		k1 = bits.RotateLeft32(k1, 15)
+		k1 *= c2_128x86
+		h1 ^= k1
+
+		h1 = bits.RotateLeft32(h1, 19)
+		h1 += h2
+		h1 = h1*5 + 0x561ccd1b
+
+		k2 *= c2_128x86
+		k2 = bits.RotateLeft32(k2, 16)
+		k2 *= c3_128x86
+		h2 ^= k2
+
+		h2 = bits.RotateLeft32(h2, 17)
+		h2 += h3
+		h2 = h2*5 + 0x0bcaa747
+
+		k3 *= c3_128x86
+		k3 = bits.RotateLeft32(k3, 17)
+		k3 *= c4_128x86
+		h3 ^= k3
+
+		h3 = bits.RotateLeft32(h3, 15)
+		h3 += h4
+		h3 = h3*5 + 0x96cd1c35
+
+		k4 *= c4_128x86
+		k4 = bits.RotateLeft32(k4, 18)
+		k4 *= c1_128x86
+		h4 ^= k4
+
+		h4 = bits.RotateLeft32(h4, 13)
+		h4 += h1
+		h4 = h4*5 + 0x32ac3b17
+	}
+	var k1, k2, k3, k4 uint32
+	switch len(data) {
+	case 15:
+		k4 ^= uint32(data[14]) << 16
+		fallthrough
+	case 14:
+		k4 ^= uint32(data[13]) << 8
+		fallthrough
+	case 13:
+		k4 ^= uint32(data[12]) << 0
+		k4 *= c4_128x86
+		k4 = bits.RotateLeft32(k4, 18)
+		k4 *= c1_128x86
+		h4 ^= k4
+		fallthrough
+
+	case 12:
+		k3 ^= uint32(data[11]) << 24
+		fallthrough
+	case 11:
+		k3 ^= uint32(data[10]) << 16
+		fallthrough
+	case 10:
+		k3 ^= uint32(data[9]) << 8
+		fallthrough
+	case 9:
+		k3 ^= uint32(data[8]) << 0
+		k3 *= c3_128x86
+		k3 = bits.RotateLeft32(k3, 17)
+		k3 *= c4_128x86
+		h3 ^= k3
+		fallthrough
+
+	case 8:
+		k2 ^= uint32(data[7]) << 24
+		fallthrough
+	case 7:
+		k2 ^= uint32(data[6]) << 16
+		fallthrough
+	case 6:
+		k2 ^= uint32(data[5]) << 8
+		fallthrough
+	case 5:
+		k2 ^= uint32(data[4]) << 0
+		k2 *= c2_128x86
+		k2 = bits.RotateLeft32(k2, 16)
+		k2 *= c3_128x86
+		h2 ^= k2
+		fallthrough
+
+	case 4:
+		k1 ^= uint32(data[3]) << 24
+		fallthrough
+	case 3:
+		k1 ^= uint32(data[2]) << 16
+		fallthrough
+	case 2:
+		k1 ^= uint32(data[1]) << 8
+		fallthrough
+	case 1:
+		k1 ^= uint32(data[0]) << 0
+		k1 *= c1_128x86
+		k1 = bits.RotateLeft32(k1, 15)
+		k1 *= c2_128x86
+		h1 ^= k1
+	}
+
+	h1 ^= uint32(clen)
+	h2 ^= uint32(clen)
+	h3 ^= uint32(clen)
+	h4 ^= uint32(clen)
+
+	h1 += h2 + h3 + h4
+	h2 += h1
+	h3 += h1
+	h4 += h1
+
+	h1 = Fmix32(h1)
+	h2 = Fmix32(h2)
+	h3 = Fmix32(h3)
+	h4 = Fmix32(h4)
+
+	h1 += h2 + h3 + h4
+	h2 += h1
+	h3 += h1
+	h4 += h1
+
+	return uint64(h2)<<32 | uint64(h1), uint64(h4)<<32 | uint64(h3)
+}
+
+// StringSum128x86 is the string version of Sum128x86.
+func StringSum128x86(data string) (uint64, uint64) {
+	return SeedStringSum128x86(0, data)
+}
+
+// SeedStringSum128x86 is the string version of SeedSum128x86.
+func SeedStringSum128x86(seed uint32, data string) (uint64, uint64) {
+	h1, h2, h3, h4 := seed, seed, seed, seed
+	clen := len(data)
+	for len(data) >= 16 {
+		k1 := uint32(data[0]) | uint32(data[1])<<8 | uint32(data[2])<<16 | uint32(data[3])<<24
+		k2 := uint32(data[4]) | uint32(data[5])<<8 | uint32(data[6])<<16 | uint32(data[7])<<24
+		k3 := uint32(data[8]) | uint32(data[9])<<8 | uint32(data[10])<<16 | uint32(data[11])<<24
+		k4 := uint32(data[12]) | uint32(data[13])<<8 | uint32(data[14])<<16 | uint32(data[15])<<24
+		data = data[16:]
+
+		k1 *= c1_128x86
+		k1 = bits.RotateLeft32(k1, 15)
+		k1 *= c2_128x86
+		h1 ^= k1
+
+		h1 = bits.RotateLeft32(h1, 19)
+		h1 += h2
+		h1 = h1*5 + 0x561ccd1b
+
+		k2 *= c2_128x86
+		k2 = bits.RotateLeft32(k2, 16)
+		k2 *= c3_128x86
+		h2 ^= k2
+
+		h2 = bits.RotateLeft32(h2, 17)
+		h2 += h3
+		h2 = h2*5 + 0x0bcaa747
+
+		k3 *= c3_128x86
+		k3 = bits.RotateLeft32(k3, 17)
+		k3 *= c4_128x86
+		h3 ^= k3
+
+		h3 = bits.RotateLeft32(h3, 15)
+		h3 += h4
+		h3 = h3*5 + 0x96cd1c35
+
+		k4 *= c4_128x86
+		k4 = bits.RotateLeft32(k4, 18)
+		k4 *= c1_128x86
+		h4 ^= k4
+
+		h4 = bits.RotateLeft32(h4, 13)
+		h4 += h1
+		h4 = h4*5 + 0x32ac3b17
+	}
+	var k1, k2, k3, k4 uint32
+	switch len(data) {
+	case 15:
+		k4 ^= uint32(data[14]) << 16
+		fallthrough
+	case 14:
+		k4 ^= uint32(data[13]) << 8
+		fallthrough
+	case 13:
+		k4 ^= uint32(data[12]) << 0
+		k4 *= c4_128x86
+		k4 = bits.RotateLeft32(k4, 18)
+		k4 *= c1_128x86
+		h4 ^= k4
+		fallthrough
+
+	case 12:
+		k3 ^= uint32(data[11]) << 24
+		fallthrough
+	case 11:
+		k3 ^= uint32(data[10]) << 16
+		fallthrough
+	case 10:
+		k3 ^= uint32(data[9]) << 8
+		fallthrough
+	case 9:
+		k3 ^= uint32(data[8]) << 0
+		k3 *= c3_128x86
+		k3 = bits.RotateLeft32(k3, 17)
+		k3 *= c4_128x86
+		h3 ^= k3
+		fallthrough
+
+	case 8:
+		k2 ^= uint32(data[7]) << 24
+		fallthrough
+	case 7:
+		k2 ^= uint32(data[6]) << 16
+		fallthrough
+	case 6:
+		k2 ^= uint32(data[5]) << 8
+		fallthrough
+	case 5:
+		k2 ^= uint32(data[4]) << 0
+		k2 *= c2_128x86
+		k2 = bits.RotateLeft32(k2, 16)
+		k2 *= c3_128x86
+		h2 ^= k2
+		fallthrough
+
+	case 4:
+		k1 ^= uint32(data[3]) << 24
+		fallthrough
+	case 3:
+		k1 ^= uint32(data[2]) << 16
+		fallthrough
+	case 2:
+		k1 ^= uint32(data[1]) << 8
+		fallthrough
+	case 1:
+		k1 ^= uint32(data[0]) << 0
+		k1 *= c1_128x86
+		k1 = bits.RotateLeft32(k1, 15)
+		k1 *= c2_128x86
+		h1 ^= k1
+	}
+
+	h1 ^= uint32(clen)
+	h2 ^= uint32(clen)
+	h3 ^= uint32(clen)
+	h4 ^= uint32(clen)
+
+	h1 += h2 + h3 + h4
+	h2 += h1
+	h3 += h1
+	h4 += h1
+
+	h1 = Fmix32(h1)
+	h2 = Fmix32(h2)
+	h3 = Fmix32(h3)
+	h4 = Fmix32(h4)
+
+	h1 += h2 + h3 + h4
+	h2 += h1
+	h3 += h1
+	h4 += h1
+
+	return uint64(h2)<<32 | uint64(h1), uint64(h4)<<32 | uint64(h3)
+}
@@ -1,7 +1,9 @@
 package murmur3
 
 import (
+	"encoding"
 	"hash"
+	"io"
 	"math/bits"
 )
 
@@ -9,6 +11,12 @@ import (
 var (
 	_ hash.Hash   = new(digest32)
 	_ hash.Hash32 = new(digest32)
+
+	_ encoding.BinaryMarshaler   = new(digest32)
+	_ encoding.BinaryUnmarshaler = new(digest32)
+
+	_ io.ReaderFrom = new(digest32)
+	_ stringWriter  = new(digest32)
 )
 
 const (
@@ -28,6 +36,9 @@ type digest32 struct {
 //
 // This reads and processes the data in chunks of little endian uint32s;
 // thus, the returned hash is portable across architectures.
+//
+// The returned hash also implements encoding.BinaryMarshaler and
+// encoding.BinaryUnmarshaler to marshal and unmarshal its internal state.
 func SeedNew32(seed uint32) hash.Hash32 {
 	d := &digest32{seed: seed}
 	d.bmixer = d
@@ -49,21 +60,51 @@ func (d *digest32) Sum(b []byte) []byte {
 	return append(b, byte(h>>24), byte(h>>16), byte(h>>8), byte(h))
 }
 
+// marshaledSize32 is the magic, seed, h1, clen and a full block.
+const marshaledSize32 = len(magic32) + 4 + 4 + 8 + 4
+
+func (d *digest32) MarshalBinary() ([]byte, error) {
+	b := make([]byte, 0, marshaledSize32)
+	b = append(b, magic32...)
+	b = appendUint32(b, d.seed)
+	b = appendUint32(b, d.h1)
+	b = d.appendState(b)
+	return b, nil
+}
+
+func (d *digest32) UnmarshalBinary(b []byte) error {
+	if len(b) < len(magic32) || string(b[:len(magic32)]) != magic32 {
+		return errStateIdentifier
+	}
+	if len(b) != marshaledSize32 || !validState(b[len(b)-8-d.Size():], d.Size()) {
+		return errStateSize
+	}
+	b = b[len(magic32):]
+	b, d.seed = consumeUint32(b)
+	b, d.h1 = consumeUint32(b)
+	d.bmixer = d
+	d.consumeState(b)
+	return nil
+}
+
 // Digest as many blocks as possible.
 func (d *digest32) bmix(p []byte) (tail []byte) {
 	h1 := d.h1
-
 	for len(p) >= 4 {
 		k1 := uint32(p[0]) | uint32(p[1])<<8 | uint32(p[2])<<16 | uint32(p[3])<<24
 		p = p[4:]
+		h1 = Mix32Block(h1, k1)
+	}
+	d.h1 = h1
+	return p
+}
 
-		k1 *= c1_32
-		k1 = bits.RotateLeft32(k1, 15)
-		k1 *= c2_32
-
-		h1 ^= k1
-		h1 = bits.RotateLeft32(h1, 13)
-		h1 = h1*5 + 0xe6546b64
+func (d *digest32) bmixString(p string) (tail string) {
+	h1 := d.h1
+	for len(p) >= 4 {
+		k1 := uint32(p[0]) | uint32(p[1])<<8 | uint32(p[2])<<16 | uint32(p[3])<<24
+		p = p[4:]
+		h1 = Mix32Block(h1, k1)
 	}
 	d.h1 = h1
 	return p
@@ -90,11 +131,5 @@ func (d *digest32) Sum32() (h1 uint32) {
 
 	h1 ^= uint32(d.clen)
 
-	h1 ^= h1 >> 16
-	h1 *= 0x85ebca6b
-	h1 ^= h1 >> 13
-	h1 *= 0xc2b2ae35
-	h1 ^= h1 >> 16
-
-	return h1
+	return Fmix32(h1)
 }
@@ -50,13 +50,7 @@ func SeedSum32(seed uint32, data []byte) (h1 uint32) {
 
 	h1 ^= uint32(clen)
 
-	h1 ^= h1 >> 16
-	h1 *= 0x85ebca6b
-	h1 ^= h1 >> 13
-	h1 *= 0xc2b2ae35
-	h1 ^= h1 >> 16
-
-	return h1
+	return Fmix32(h1)
 }
 
 // StringSum32 is the string version of Sum32.
@@ -98,11 +92,5 @@ func SeedStringSum32(seed uint32, data string) (h1 uint32) {
 
 	h1 ^= uint32(clen)
 
-	h1 ^= h1 >> 16
-	h1 *= 0x85ebca6b
-	h1 ^= h1 >> 13
-	h1 *= 0xc2b2ae35
-	h1 ^= h1 >> 16
-
-	return h1
+	return Fmix32(h1)
 }
@@ -1,7 +1,9 @@
 package murmur3
 
 import (
+	"encoding"
 	"hash"
+	"io"
 )
 
 // Make sure interfaces are correctly implemented.
@@ -9,6 +11,12 @@ var (
 	_ hash.Hash   = new(digest64)
 	_ hash.Hash64 = new(digest64)
 	_ bmixer      = new(digest64)
+
+	_ encoding.BinaryMarshaler   = new(digest64)
+	_ encoding.BinaryUnmarshaler = new(digest64)
+
+	_ io.ReaderFrom = new(digest64)
+	_ stringWriter  = new(digest64)
 )
 
 // digest64 is half a digest128.
@@ -16,6 +24,9 @@ type digest64 digest128
 
 // SeedNew64 returns a hash.Hash64 for streaming 64 bit sums. As the canonical
 // implementation does not support Sum64, this uses SeedNew128(seed, seed)
+//
+// The returned hash also implements encoding.BinaryMarshaler and
+// encoding.BinaryUnmarshaler to marshal and unmarshal its internal state.
 func SeedNew64(seed uint64) hash.Hash64 {
 	return (*digest64)(SeedNew128(seed, seed).(*digest128))
 }
@@ -37,6 +48,14 @@ func (d *digest64) Sum64() uint64 {
 	return h1
 }
 
+func (d *digest64) MarshalBinary() ([]byte, error) {
+	return (*digest128)(d).marshal(magic64), nil
+}
+
+func (d *digest64) UnmarshalBinary(b []byte) error {
+	return (*digest128)(d).unmarshal(magic64, b)
+}
+
 // Sum64 returns the murmur3 sum of data. It is equivalent to the following
 // sequence (without the extra burden and the extra allocation):
 //     hasher := New64()
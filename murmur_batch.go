@@ -0,0 +1,94 @@
+package murmur3
+
+// Sum32Batch sets out[i] to SeedSum32(seed, keys[i]) for every key. out must
+// be at least as long as keys.
+func Sum32Batch(seed uint32, keys [][]byte, out []uint32) {
+	out = out[:len(keys)]
+	for i, key := range keys {
+		out[i] = SeedSum32(seed, key)
+	}
+}
+
+// StringSum32Batch is the string version of Sum32Batch.
+func StringSum32Batch(seed uint32, keys []string, out []uint32) {
+	out = out[:len(keys)]
+	for i, key := range keys {
+		out[i] = SeedStringSum32(seed, key)
+	}
+}
+
+// batchChunk is how many keys each call into the batch hashing handles. The
+// amd64 batch hashing is assembly that cannot be preempted, so large batches
+// are split to bound how long it runs; Sum64Batch also gathers this many 128
+// bit sums on the stack before keeping the halves it needs.
+const batchChunk = 32
+
+// Sum64Batch sets out[i] to SeedSum64(seed, keys[i]) for every key. out must
+// be at least as long as keys.
+func Sum64Batch(seed uint64, keys [][]byte, out []uint64) {
+	out = out[:len(keys)]
+	var sums [batchChunk][2]uint64
+	for len(keys) > 0 {
+		n := len(keys)
+		if n > batchChunk {
+			n = batchChunk
+		}
+		sum128Batch(seed, seed, keys[:n], sums[:n])
+		for i := 0; i < n; i++ {
+			out[i] = sums[i][0]
+		}
+		keys, out = keys[n:], out[n:]
+	}
+}
+
+// StringSum64Batch is the string version of Sum64Batch.
+func StringSum64Batch(seed uint64, keys []string, out []uint64) {
+	out = out[:len(keys)]
+	var sums [batchChunk][2]uint64
+	for len(keys) > 0 {
+		n := len(keys)
+		if n > batchChunk {
+			n = batchChunk
+		}
+		stringSum128Batch(seed, seed, keys[:n], sums[:n])
+		for i := 0; i < n; i++ {
+			out[i] = sums[i][0]
+		}
+		keys, out = keys[n:], out[n:]
+	}
+}
+
+// Sum128Batch sets out[i] to SeedSum128(seed1, seed2, keys[i]) for every key,
+// with h1 in out[i][0] and h2 in out[i][1]. out must be at least as long as
+// keys.
+//
+// This avoids the per call overhead of hashing many short keys. On amd64,
+// keys are additionally hashed in pairs with their block mixing interleaved,
+// hiding the multiply latency that bounds a single hash. The gain is modest:
+// Benchmark128Batch measures about 10 to 15 percent more throughput than a
+// loop over SeedSum128 for keys of 8 to 64 bytes, within noise at some sizes.
+// Elsewhere, Sum128Batch is such a loop.
+func Sum128Batch(seed1, seed2 uint64, keys [][]byte, out [][2]uint64) {
+	out = out[:len(keys)]
+	for len(keys) > 0 {
+		n := len(keys)
+		if n > batchChunk {
+			n = batchChunk
+		}
+		sum128Batch(seed1, seed2, keys[:n], out[:n])
+		keys, out = keys[n:], out[n:]
+	}
+}
+
+// StringSum128Batch is the string version of Sum128Batch.
+func StringSum128Batch(seed1, seed2 uint64, keys []string, out [][2]uint64) {
+	out = out[:len(keys)]
+	for len(keys) > 0 {
+		n := len(keys)
+		if n > batchChunk {
+			n = batchChunk
+		}
+		stringSum128Batch(seed1, seed2, keys[:n], out[:n])
+		keys, out = keys[n:], out[n:]
+	}
+}
@@ -0,0 +1,15 @@
+//go:build go1.5 && amd64 && !gccgo && !purego
+// +build go1.5,amd64,!gccgo,!purego
+
+package murmur3
+
+//go:noescape
+
+// sum128Batch sets out[i] to the SeedSum128 of keys[i]. Keys are hashed in
+// pairs, with the blocks a pair has in common mixed together.
+func sum128Batch(seed1, seed2 uint64, keys [][]byte, out [][2]uint64)
+
+//go:noescape
+
+// stringSum128Batch is the string version of sum128Batch.
+func stringSum128Batch(seed1, seed2 uint64, keys []string, out [][2]uint64)
@@ -0,0 +1,18 @@
+//go:build !go1.5 || !amd64 || gccgo || purego
+// +build !go1.5 !amd64 gccgo purego
+
+package murmur3
+
+// sum128Batch sets out[i] to the SeedSum128 of keys[i].
+func sum128Batch(seed1, seed2 uint64, keys [][]byte, out [][2]uint64) {
+	for i, key := range keys {
+		out[i][0], out[i][1] = SeedSum128(seed1, seed2, key)
+	}
+}
+
+// stringSum128Batch is the string version of sum128Batch.
+func stringSum128Batch(seed1, seed2 uint64, keys []string, out [][2]uint64) {
+	for i, key := range keys {
+		out[i][0], out[i][1] = SeedStringSum128(seed1, seed2, key)
+	}
+}
@@ -0,0 +1,96 @@
+package murmur3
+
+import "math/bits"
+
+// The functions below are the building blocks of the hashes in this package,
+// for composing custom hashes, such as combining precomputed partial hashes.
+//
+// SeedSum32(seed, data), for data whose length is a multiple of four, is
+//
+//	h1 := seed
+//	for each little endian uint32 block k1 of data {
+//		h1 = Mix32Block(h1, k1)
+//	}
+//	return Fmix32(h1 ^ uint32(len(data)))
+//
+// and SeedSum128(seed1, seed2, data), for data whose length is a multiple of
+// sixteen, is
+//
+//	h1, h2 := seed1, seed2
+//	for each pair of little endian uint64 blocks k1, k2 of data {
+//		h1, h2 = Mix128Block(h1, h2, k1, k2)
+//	}
+//	h1 ^= uint64(len(data))
+//	h2 ^= uint64(len(data))
+//	h1 += h2
+//	h2 += h1
+//	h1, h2 = Fmix64(h1), Fmix64(h2)
+//	h1 += h2
+//	h2 += h1
+//	return h1, h2
+//
+// Data of other lengths ends with a partial block, which murmur3 mixes into
+// the state differently; see the reference implementation.
+
+// Mix32Block returns the x86_32 state h1 after mixing in the block k1, which
+// is the next four bytes of data read as a little endian uint32.
+func Mix32Block(h1, k1 uint32) uint32 {
+	k1 *= c1_32
+	k1 = bits.RotateLeft32(k1, 15)
+	k1 *= c2_32
+
+	h1 ^= k1
+	h1 = bits.RotateLeft32(h1, 13)
+	return h1*5 + 0xe6546b64
+}
+
+// Mix128Block returns the x64_128 state h1, h2 after mixing in the blocks k1
+// and k2, which are the next sixteen bytes of data read as two little endian
+// uint64s.
+func Mix128Block(h1, h2, k1, k2 uint64) (uint64, uint64) {
+	k1 *= c1_128
+	k1 = bits.RotateLeft64(k1, 31)
+	k1 *= c2_128
+	h1 ^= k1
+
+	h1 = bits.RotateLeft64(h1, 27)
+	h1 += h2
+	h1 = h1*5 + 0x52dce729
+
+	k2 *= c2_128
+	k2 = bits.RotateLeft64(k2, 33)
+	k2 *= c1_128
+	h2 ^= k2
+
+	h2 = bits.RotateLeft64(h2, 31)
+	h2 += h1
+	h2 = h2*5 + 0x38495ab5
+
+	return h1, h2
+}
+
+// Fmix32 is murmur3's 32 bit finalizer, which Sum32 and the x86_128 hashes
+// apply to their state. It is a bijection that avalanches every bit of h into
+// every bit of the result, which also makes it a fast, good hash of a single
+// uint32 on its own. Fmix32(0) is 0.
+func Fmix32(h uint32) uint32 {
+	h ^= h >> 16
+	h *= 0x85ebca6b
+	h ^= h >> 13
+	h *= 0xc2b2ae35
+	h ^= h >> 16
+	return h
+}
+
+// Fmix64 is murmur3's 64 bit finalizer, which the 128 bit hashes apply to
+// each half of their state. It is a bijection that avalanches every bit of k
+// into every bit of the result, which also makes it a fast, good hash of a
+// single uint64 on its own. Fmix64(0) is 0.
+func Fmix64(k uint64) uint64 {
+	k ^= k >> 33
+	k *= 0xff51afd7ed558ccd
+	k ^= k >> 33
+	k *= 0xc4ceb9fe1a85ec53
+	k ^= k >> 33
+	return k
+}
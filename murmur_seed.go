@@ -0,0 +1,269 @@
+package murmur3
+
+import (
+	"crypto/rand"
+	"math"
+	"reflect"
+)
+
+// Seed is a random 128 bit seed, in the spirit of hash/maphash.Seed, for
+// hashing keys chosen by others, such as strings from requests, into hash
+// tables. The hashes of a Seed are SeedSum128 with the two halves of the seed
+// as seed1 and seed2; which key collides with which changes from seed to
+// seed, so a fixed list of colliding keys made against seed 0 does not carry
+// over.
+//
+// A seed does not make murmur3 safe against an attacker who adapts to it.
+// murmur3 has seed independent multicollisions: two 32 byte messages whose
+// block mixing differs only in bits that cancel out, whatever the running
+// hash, collide under every seed, and concatenating n such pairs gives 2^n
+// messages with one hash. Where keys may be crafted against the algorithm,
+// use a keyed hash such as SipHash, or hash/maphash.
+//
+// The zero value is not a valid seed; create seeds with MakeSeed. Seeds are
+// comparable and safe for concurrent use.
+type Seed struct {
+	s1, s2 uint64
+}
+
+// MakeSeed returns a new random seed read from crypto/rand. It panics if
+// crypto/rand fails.
+func MakeSeed() Seed {
+	var b [16]byte
+	for {
+		if _, err := rand.Read(b[:]); err != nil {
+			panic("murmur3: unable to read a random seed: " + err.Error())
+		}
+		_, s1 := consumeUint64(b[:8])
+		_, s2 := consumeUint64(b[8:])
+		if s1|s2 != 0 {
+			return Seed{s1, s2}
+		}
+	}
+}
+
+func (s Seed) check() {
+	if s.s1|s.s2 == 0 {
+		panic("murmur3: use of uninitialized Seed")
+	}
+}
+
+// Sum64 returns the first half of s.Sum128.
+func (s Seed) Sum64(data []byte) uint64 {
+	s.check()
+	h1, _ := SeedSum128(s.s1, s.s2, data)
+	return h1
+}
+
+// StringSum64 is the string version of Sum64.
+func (s Seed) StringSum64(data string) uint64 {
+	s.check()
+	h1, _ := SeedStringSum128(s.s1, s.s2, data)
+	return h1
+}
+
+// Sum128 returns SeedSum128 of data seeded with s.
+func (s Seed) Sum128(data []byte) (h1, h2 uint64) {
+	s.check()
+	return SeedSum128(s.s1, s.s2, data)
+}
+
+// StringSum128 is the string version of Sum128.
+func (s Seed) StringSum128(data string) (h1, h2 uint64) {
+	s.check()
+	return SeedStringSum128(s.s1, s.s2, data)
+}
+
+// New128 returns a Hash128 for streaming 128 bit sums seeded with s.
+//
+// The marshaled state of the returned hash contains the seed, and so must be
+// kept as secret as the seed itself.
+func (s Seed) New128() Hash128 {
+	s.check()
+	return SeedNew128(s.s1, s.s2)
+}
+
+// Comparable returns a 64 bit hash of v for keying a hash table, such as
+// Map, such that values equal under == hash equal. Equal values of different dynamic types
+// are not equal under ==, and may or may not hash equal.
+//
+// Pointers, channels and unsafe pointers hash by address; floating point
+// zeros hash equal regardless of sign. Comparable panics if v is or holds a
+// value, such as a slice, map or function, that cannot be compared.
+func (s Seed) Comparable(v interface{}) uint64 {
+	s.check()
+	var buf [64]byte
+	var b []byte
+	switch v := v.(type) {
+	case string:
+		h1, _ := SeedStringSum128(s.s1, s.s2, v)
+		return h1
+	case int:
+		b = appendUint64LE(buf[:0], uint64(v))
+	case int64:
+		b = appendUint64LE(buf[:0], uint64(v))
+	case uint64:
+		b = appendUint64LE(buf[:0], v)
+	default:
+		b = appendComparable(buf[:0], reflect.ValueOf(v))
+	}
+	h1, _ := SeedSum128(s.s1, s.s2, b)
+	return h1
+}
+
+// appendComparable appends the little endian encoding of v's value, with
+// strings length prefixed so that struct and array fields cannot run
+// together.
+func appendComparable(b []byte, v reflect.Value) []byte {
+	switch v.Kind() {
+	case reflect.Invalid:
+		return append(b, 0)
+	case reflect.Bool:
+		if v.Bool() {
+			return append(b, 1)
+		}
+		return append(b, 0)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return appendUint64LE(b, uint64(v.Int()))
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return appendUint64LE(b, v.Uint())
+	case reflect.Float32, reflect.Float64:
+		return appendFloatLE(b, v.Float())
+	case reflect.Complex64, reflect.Complex128:
+		c := v.Complex()
+		return appendFloatLE(appendFloatLE(b, real(c)), imag(c))
+	case reflect.String:
+		s := v.String()
+		return append(appendUint64LE(b, uint64(len(s))), s...)
+	case reflect.Ptr, reflect.Chan, reflect.UnsafePointer:
+		return appendUint64LE(b, uint64(v.Pointer()))
+	case reflect.Interface:
+		return appendComparable(b, v.Elem())
+	case reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			b = appendComparable(b, v.Index(i))
+		}
+		return b
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			b = appendComparable(b, v.Field(i))
+		}
+		return b
+	}
+	panic("murmur3: Comparable of uncomparable type " + v.Type().String())
+}
+
+// appendFloatLE appends f widened to 64 bits, with -0 as 0 so that the equal
+// zeros hash equal.
+func appendFloatLE(b []byte, f float64) []byte {
+	if f == 0 {
+		f = 0
+	}
+	return appendUint64LE(b, math.Float64bits(f))
+}
+
+// Map is a hash table from comparable keys to values, with buckets chosen by
+// Seed.Comparable under a random seed of its own. Go's built in maps are
+// already randomly seeded; Map is for code that wants the hash spelled out,
+// such as a table whose keys must also be sharded with the same seed.
+//
+// Because of murmur3's seed independent multicollisions (see Seed), crafted
+// keys of 32 or more bytes can still be made to share one bucket, turning
+// lookups linear in the number of such keys.
+//
+// The zero value is an empty map ready to use. Keys must be comparable, as
+// for Comparable. A Map is not safe for concurrent use.
+type Map struct {
+	seed    Seed
+	buckets [][]mapEntry
+	n       int
+}
+
+type mapEntry struct {
+	hash       uint64
+	key, value interface{}
+}
+
+// mapMinBuckets is how many buckets a Map starts with; it doubles its
+// buckets once it holds twice as many entries.
+const mapMinBuckets = 8
+
+// Len returns the number of entries in the map.
+func (m *Map) Len() int { return m.n }
+
+// Get returns the value stored for key and whether there was one.
+func (m *Map) Get(key interface{}) (value interface{}, ok bool) {
+	if m.n == 0 {
+		return nil, false
+	}
+	h := m.seed.Comparable(key)
+	for _, e := range m.buckets[h&uint64(len(m.buckets)-1)] {
+		if e.hash == h && e.key == key {
+			return e.value, true
+		}
+	}
+	return nil, false
+}
+
+// Set stores value for key, replacing any value already stored.
+func (m *Map) Set(key, value interface{}) {
+	if m.buckets == nil {
+		m.seed = MakeSeed()
+		m.buckets = make([][]mapEntry, mapMinBuckets)
+	}
+	h := m.seed.Comparable(key)
+	bucket := &m.buckets[h&uint64(len(m.buckets)-1)]
+	for i := range *bucket {
+		if e := &(*bucket)[i]; e.hash == h && e.key == key {
+			e.value = value
+			return
+		}
+	}
+	*bucket = append(*bucket, mapEntry{h, key, value})
+	m.n++
+	if m.n > 2*len(m.buckets) {
+		m.grow()
+	}
+}
+
+// Delete removes the entry for key, if any.
+func (m *Map) Delete(key interface{}) {
+	if m.n == 0 {
+		return
+	}
+	h := m.seed.Comparable(key)
+	bucket := &m.buckets[h&uint64(len(m.buckets)-1)]
+	for i, e := range *bucket {
+		if e.hash == h && e.key == key {
+			last := len(*bucket) - 1
+			(*bucket)[i] = (*bucket)[last]
+			(*bucket)[last] = mapEntry{}
+			*bucket = (*bucket)[:last]
+			m.n--
+			return
+		}
+	}
+}
+
+// Range calls f for each entry in the map, in no particular order, until f
+// returns false. f must not modify the map.
+func (m *Map) Range(f func(key, value interface{}) bool) {
+	for _, bucket := range m.buckets {
+		for _, e := range bucket {
+			if !f(e.key, e.value) {
+				return
+			}
+		}
+	}
+}
+
+func (m *Map) grow() {
+	buckets := make([][]mapEntry, 2*len(m.buckets))
+	for _, bucket := range m.buckets {
+		for _, e := range bucket {
+			i := e.hash & uint64(len(buckets)-1)
+			buckets[i] = append(buckets[i], e)
+		}
+	}
+	m.buckets = buckets
+}
@@ -0,0 +1,113 @@
+package murmur3
+
+// SetHash128 accumulates an order independent hash of a set: the XOR of the
+// Sum128 of every element, finalized with Fmix64. Two accumulators that saw
+// the same elements, in any order, have the same Sum128.
+//
+// Because XOR cancels, adding an element already in the set removes it
+// again, so Add must only be given elements not yet in the set, and Remove
+// only elements in it. When that cannot be guaranteed, use
+// MultisetHash128.
+//
+// The zero value is the hash of the empty set.
+type SetHash128 struct {
+	h1, h2 uint64
+}
+
+// Add adds data, which must not already be in the set.
+func (s *SetHash128) Add(data []byte) {
+	h1, h2 := Sum128(data)
+	s.h1 ^= h1
+	s.h2 ^= h2
+}
+
+// AddString is the string version of Add.
+func (s *SetHash128) AddString(data string) {
+	h1, h2 := StringSum128(data)
+	s.h1 ^= h1
+	s.h2 ^= h2
+}
+
+// Remove removes data, which must be in the set. For a SetHash128, Remove
+// and Add are the same operation.
+func (s *SetHash128) Remove(data []byte) { s.Add(data) }
+
+// RemoveString is the string version of Remove.
+func (s *SetHash128) RemoveString(data string) { s.AddString(data) }
+
+// Merge adds every element of other, which must be disjoint from s, leaving
+// s the hash of the union.
+func (s *SetHash128) Merge(other *SetHash128) {
+	s.h1 ^= other.h1
+	s.h2 ^= other.h2
+}
+
+// Sum128 returns the hash of the set.
+func (s *SetHash128) Sum128() (h1, h2 uint64) {
+	return finalizeAccum(s.h1, s.h2)
+}
+
+// MultisetHash128 accumulates an order independent hash of a multiset: the
+// sum, modulo 2^64 in each half, of the Sum128 of every element, finalized
+// with Fmix64. Each occurrence of an element counts, so a multiset with an
+// element twice hashes differently from one with it once, and Remove undoes
+// exactly one Add.
+//
+// The zero value is the hash of the empty multiset.
+type MultisetHash128 struct {
+	h1, h2 uint64
+}
+
+// Add adds one occurrence of data.
+func (m *MultisetHash128) Add(data []byte) {
+	h1, h2 := Sum128(data)
+	m.h1 += h1
+	m.h2 += h2
+}
+
+// AddString is the string version of Add.
+func (m *MultisetHash128) AddString(data string) {
+	h1, h2 := StringSum128(data)
+	m.h1 += h1
+	m.h2 += h2
+}
+
+// Remove removes one occurrence of data. Removing an element that was never
+// added leaves a hash that no multiset has, until the element is added back.
+func (m *MultisetHash128) Remove(data []byte) {
+	h1, h2 := Sum128(data)
+	m.h1 -= h1
+	m.h2 -= h2
+}
+
+// RemoveString is the string version of Remove.
+func (m *MultisetHash128) RemoveString(data string) {
+	h1, h2 := StringSum128(data)
+	m.h1 -= h1
+	m.h2 -= h2
+}
+
+// Merge adds every occurrence in other, leaving m the hash of the multiset
+// sum.
+func (m *MultisetHash128) Merge(other *MultisetHash128) {
+	m.h1 += other.h1
+	m.h2 += other.h2
+}
+
+// Sum128 returns the hash of the multiset.
+func (m *MultisetHash128) Sum128() (h1, h2 uint64) {
+	return finalizeAccum(m.h1, m.h2)
+}
+
+// finalizeAccum mixes the two halves of an accumulator into each other the
+// way the 128 bit hashes finish, so that every bit of the result depends on
+// every element.
+func finalizeAccum(h1, h2 uint64) (uint64, uint64) {
+	h1 += h2
+	h2 += h1
+	h1 = Fmix64(h1)
+	h2 = Fmix64(h2)
+	h1 += h2
+	h2 += h1
+	return h1, h2
+}
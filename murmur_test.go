@@ -1,13 +1,18 @@
 package murmur3
 
 import (
+	"bytes"
 	"crypto/rand"
+	"encoding"
 	"encoding/binary"
 	"fmt"
 	"hash"
 	"io"
+	"math"
+	"math/bits"
 	"strconv"
 	"testing"
+	"testing/iotest"
 	"testing/quick"
 	"unsafe"
 
@@ -188,6 +193,44 @@ func TestQuickSeedSum128(t *testing.T) {
 	}
 }
 
+func TestQuickSum128x86(t *testing.T) {
+	f := func(data []byte) bool {
+		goh1, goh2 := Sum128x86(data)
+		goh3, goh4 := StringSum128x86(string(data))
+		cpph1, cpph2 := goh1, goh2
+		if isLittleEndian {
+			cpph1, cpph2 = testdata.SeedSum128x86(0, data)
+		}
+		return goh1 == goh3 && goh2 == goh4 && goh1 == cpph1 && goh2 == cpph2
+	}
+	if err := quick.Check(f, nil); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestQuickSeedSum128x86(t *testing.T) {
+	f := func(seed uint32, data []byte) bool {
+		goh1, goh2 := SeedSum128x86(seed, data)
+		goh3, goh4 := SeedStringSum128x86(seed, string(data))
+		goh5, goh6 := func() (uint64, uint64) {
+			h := SeedNew128x86(seed)
+			h.Write(data)
+			sum := h.Sum(nil)
+			return binary.BigEndian.Uint64(sum), binary.BigEndian.Uint64(sum[8:])
+		}()
+		cpph1, cpph2 := goh1, goh2
+		if isLittleEndian {
+			cpph1, cpph2 = testdata.SeedSum128x86(seed, data)
+		}
+		return goh1 == goh3 && goh2 == goh4 &&
+			goh1 == goh5 && goh2 == goh6 &&
+			goh1 == cpph1 && goh2 == cpph2
+	}
+	if err := quick.Check(f, nil); err != nil {
+		t.Error(err)
+	}
+}
+
 // go1.14 showed that doing *(*uint32)(unsafe.Pointer(&data[i*4])) was unsafe
 // due to alignment issues; this test ensures that we will always catch that.
 func TestUnaligned(t *testing.T) {
@@ -288,6 +331,14 @@ func TestBoundaries(t *testing.T) {
 			if g128h2s != c128h2 {
 				t.Errorf("size #%d: in: %x, g128h2s (%d) != c128h2 (%d); attempt #%d", size, test, g128h2s, c128h2, i)
 			}
+			gx86h1, gx86h2 := Sum128x86(test)
+			cx86h1, cx86h2 := gx86h1, gx86h2
+			if isLittleEndian {
+				cx86h1, cx86h2 = testdata.SeedSum128x86(0, test)
+			}
+			if gx86h1 != cx86h1 || gx86h2 != cx86h2 {
+				t.Errorf("size #%d: in: %x, gx86 (%d-%d) != cx86 (%d-%d); attempt #%d", size, test, gx86h1, gx86h2, cx86h1, cx86h2, i)
+			}
 		}
 		// Randomize the data for all subsequent tests.
 		io.ReadFull(rand.Reader, data[:])
@@ -298,6 +349,7 @@ func TestIncremental(t *testing.T) {
 	for _, elem := range data {
 		h32 := New32()
 		h128 := New128()
+		h128x86 := New128x86()
 		for i, j, k := 0, 0, len(elem.s); i < k; i = j {
 			j = 2*i + 3
 			if j > k {
@@ -307,6 +359,7 @@ func TestIncremental(t *testing.T) {
 			print(s + "|")
 			h32.Write([]byte(s))
 			h128.Write([]byte(s))
+			h128x86.Write([]byte(s))
 		}
 		println()
 		if v := h32.Sum32(); v != elem.h32 {
@@ -315,6 +368,687 @@ func TestIncremental(t *testing.T) {
 		if v1, v2 := h128.Sum128(); v1 != elem.h64_1 || v2 != elem.h64_2 {
 			t.Errorf("'%s': 0x%x-0x%x (want 0x%x-0x%x)", elem.s, v1, v2, elem.h64_1, elem.h64_2)
 		}
+		v1, v2 := h128x86.Sum128()
+		if e1, e2 := StringSum128x86(elem.s); v1 != e1 || v2 != e2 {
+			t.Errorf("x86: '%s': 0x%x-0x%x (want 0x%x-0x%x)", elem.s, v1, v2, e1, e2)
+		}
+	}
+}
+
+func TestWriteStringReadFrom(t *testing.T) {
+	type streamHash interface {
+		hash.Hash
+		io.ReaderFrom
+		WriteString(string) (int, error)
+	}
+	hashers := []func() streamHash{
+		func() streamHash { return New32().(streamHash) },
+		func() streamHash { return New64().(streamHash) },
+		func() streamHash { return New128().(streamHash) },
+		func() streamHash { return New128x86().(streamHash) },
+	}
+
+	input := make([]byte, 100<<10+13)
+	io.ReadFull(rand.Reader, input)
+	for _, length := range []int{0, 1, 3, 15, 16, 17, 100, 32 << 10, 32<<10 + 1, len(input)} {
+		data := input[:length]
+		for i, newHash := range hashers {
+			ref := newHash()
+			ref.Write(data)
+			exp := ref.Sum(nil)
+
+			// Strings in uneven pieces, interleaved with Write.
+			h := newHash()
+			for p, n := data, 1; len(p) > 0; n = 2*n + 1 {
+				if n > len(p) {
+					n = len(p)
+				}
+				if n&2 == 0 {
+					h.WriteString(string(p[:n]))
+				} else {
+					h.Write(p[:n])
+				}
+				p = p[n:]
+			}
+			if got := h.Sum(nil); !bytes.Equal(got, exp) {
+				t.Errorf("#%d, len %d: WriteString sum %x != %x", i, length, got, exp)
+			}
+
+			// ReadFrom after a pending tail, from readers returning
+			// short reads.
+			pending := 5
+			if length < pending {
+				pending = length
+			}
+			for _, r := range []io.Reader{
+				bytes.NewReader(data[pending:]),
+				iotest.HalfReader(bytes.NewReader(data[pending:])),
+				iotest.OneByteReader(bytes.NewReader(data[pending:])),
+			} {
+				h := newHash()
+				h.Write(data[:pending])
+				n, err := h.ReadFrom(r)
+				if err != nil || n != int64(length-pending) {
+					t.Errorf("#%d, len %d: ReadFrom = %d, %v", i, length, n, err)
+				}
+				if got := h.Sum(nil); !bytes.Equal(got, exp) {
+					t.Errorf("#%d, len %d: ReadFrom sum %x != %x", i, length, got, exp)
+				}
+			}
+
+			// Data read before an error is hashed.
+			h = newHash()
+			n, err := h.ReadFrom(iotest.TimeoutReader(bytes.NewReader(data)))
+			if length > 32<<10 && err != iotest.ErrTimeout {
+				t.Errorf("#%d, len %d: ReadFrom error %v", i, length, err)
+			}
+			h.ReadFrom(bytes.NewReader(data[n:]))
+			if got := h.Sum(nil); !bytes.Equal(got, exp) {
+				t.Errorf("#%d, len %d: sum after error %x != %x", i, length, got, exp)
+			}
+		}
+	}
+}
+
+func TestTreeSum128(t *testing.T) {
+	// treeRef is TreeSum128 as documented.
+	treeRef := func(data []byte, chunkSize int) (uint64, uint64) {
+		var sums []byte
+		for {
+			n := chunkSize
+			if n > len(data) {
+				n = len(data)
+			}
+			h1, h2 := SeedSum128(0, 0, data[:n])
+			var b [16]byte
+			binary.LittleEndian.PutUint64(b[:], h1)
+			binary.LittleEndian.PutUint64(b[8:], h2)
+			sums = append(sums, b[:]...)
+			if data = data[n:]; len(data) == 0 {
+				break
+			}
+		}
+		return SeedSum128(uint64(chunkSize), uint64(chunkSize), sums)
+	}
+
+	input := make([]byte, 10000)
+	io.ReadFull(rand.Reader, input)
+	for _, chunkSize := range []int{1, 7, 64, 1000} {
+		for _, length := range []int{0, 1, chunkSize - 1, chunkSize, chunkSize + 1, 3 * chunkSize, len(input)} {
+			if length < 0 {
+				continue
+			}
+			data := input[:length]
+			exp1, exp2 := treeRef(data, chunkSize)
+			if s1, s2 := Sum128(data); s1 == exp1 && s2 == exp2 {
+				t.Errorf("chunk %d, len %d: tree sum equals Sum128", chunkSize, length)
+			}
+			for _, workers := range []int{0, 1, 2, 5, 64} {
+				if h1, h2 := TreeSum128(data, chunkSize, workers); h1 != exp1 || h2 != exp2 {
+					t.Errorf("chunk %d, len %d, %d workers: got %x %x != exp %x %x",
+						chunkSize, length, workers, h1, h2, exp1, exp2)
+				}
+
+				h := NewTreeHash128(chunkSize, workers)
+				for p, n := data, 1; len(p) > 0; n = 3*n + 1 {
+					if n > len(p) {
+						n = len(p)
+					}
+					h.Write(p[:n])
+					p = p[n:]
+					// Sum must not disturb further writes.
+					h.Sum128()
+				}
+				if h1, h2 := h.Sum128(); h1 != exp1 || h2 != exp2 {
+					t.Errorf("chunk %d, len %d, %d workers: streaming got %x %x != exp %x %x",
+						chunkSize, length, workers, h1, h2, exp1, exp2)
+				}
+				h.Reset()
+				h.Write(data)
+				if h1, h2 := h.Sum128(); h1 != exp1 || h2 != exp2 {
+					t.Errorf("chunk %d, len %d, %d workers: after Reset got %x %x", chunkSize, length, workers, h1, h2)
+				}
+			}
+		}
+	}
+
+	h1, h2 := TreeSum128(input, 0, 0)
+	if e1, e2 := TreeSum128(input, DefaultTreeChunkSize, 1); h1 != e1 || h2 != e2 {
+		t.Error("default parameters do not use DefaultTreeChunkSize")
+	}
+
+	// Huge chunk sizes limit the workers rather than overflowing.
+	for _, chunkSize := range []int{maxInt, maxInt/3 + 1} {
+		if _, workers := treeParams(chunkSize, 8); workers < 1 || workers > maxInt/chunkSize {
+			t.Errorf("chunk %d: %d workers", chunkSize, workers)
+		}
+		e1, e2 := TreeSum128(input, chunkSize, 1)
+		if h1, h2 := TreeSum128(input, chunkSize, 8); h1 != e1 || h2 != e2 {
+			t.Errorf("chunk %d: TreeSum128 depends on the workers", chunkSize)
+		}
+		h := NewTreeHash128(chunkSize, 8)
+		h.Write(input)
+		if h1, h2 := h.Sum128(); h1 != e1 || h2 != e2 {
+			t.Errorf("chunk %d: streaming got %x %x != exp %x %x", chunkSize, h1, h2, e1, e2)
+		}
+	}
+
+	// The buffer grows with what is written rather than up front.
+	h := NewTreeHash128(1<<20, 8).(*treeDigest128)
+	h.Write(input[:10])
+	if c := cap(h.buf); c > 1<<10 {
+		t.Errorf("10 byte write buffered in %d bytes", c)
+	}
+}
+
+func TestSetHash128(t *testing.T) {
+	var a, b, c SetHash128
+	for i := 0; i < 100; i++ {
+		a.AddString(strconv.Itoa(i))
+	}
+	for i := 99; i >= 0; i-- {
+		b.Add([]byte(strconv.Itoa(i)))
+	}
+	if a != b {
+		t.Error("insertion order changed the set hash")
+	}
+
+	// Removal and merging of disjoint halves.
+	b.RemoveString("42")
+	b.Remove([]byte("7"))
+	c.AddString("7")
+	c.AddString("42")
+	if b == a {
+		t.Error("removal did not change the set hash")
+	}
+	b.Merge(&c)
+	if b != a {
+		t.Error("merge did not restore the set hash")
+	}
+
+	var empty SetHash128
+	if h1, h2 := empty.Sum128(); h1 != 0 || h2 != 0 {
+		t.Errorf("empty set: %x %x", h1, h2)
+	}
+	if h1, h2 := a.Sum128(); h1 == a.h1 && h2 == a.h2 {
+		t.Error("Sum128 did not finalize")
+	}
+}
+
+func TestMultisetHash128(t *testing.T) {
+	var a, b MultisetHash128
+	for _, s := range []string{"x", "y", "x", "z"} {
+		a.AddString(s)
+	}
+	for _, s := range []string{"z", "x", "y", "x"} {
+		b.Add([]byte(s))
+	}
+	if a != b {
+		t.Error("insertion order changed the multiset hash")
+	}
+
+	// Multiplicity counts, unlike in a set.
+	var once MultisetHash128
+	for _, s := range []string{"x", "y", "z"} {
+		once.AddString(s)
+	}
+	if a == once {
+		t.Error("multiset with x twice hashes as with x once")
+	}
+	a.RemoveString("x")
+	if a != once {
+		t.Error("Remove did not undo one Add")
+	}
+	a.Remove([]byte("y"))
+	a.Remove([]byte("y"))
+
+	var back MultisetHash128
+	back.AddString("y")
+	back.AddString("y")
+	a.Merge(&back)
+	if a != once {
+		t.Error("Merge did not restore the multiset hash")
+	}
+}
+
+func TestSeed(t *testing.T) {
+	s := MakeSeed()
+	if s == MakeSeed() {
+		t.Error("MakeSeed returned the same seed twice")
+	}
+	data := []byte("hello, world")
+	h1, h2 := SeedSum128(s.s1, s.s2, data)
+	if g1, g2 := s.Sum128(data); g1 != h1 || g2 != h2 {
+		t.Errorf("Sum128 = %x %x, want %x %x", g1, g2, h1, h2)
+	}
+	if g1, g2 := s.StringSum128(string(data)); g1 != h1 || g2 != h2 {
+		t.Errorf("StringSum128 = %x %x, want %x %x", g1, g2, h1, h2)
+	}
+	if g := s.Sum64(data); g != h1 {
+		t.Errorf("Sum64 = %x, want %x", g, h1)
+	}
+	if g := s.StringSum64(string(data)); g != h1 {
+		t.Errorf("StringSum64 = %x, want %x", g, h1)
+	}
+	d := s.New128()
+	d.Write(data)
+	if g1, g2 := d.Sum128(); g1 != h1 || g2 != h2 {
+		t.Errorf("New128 = %x %x, want %x %x", g1, g2, h1, h2)
+	}
+
+	// Each half of the seed must reach each half of the hash: changing
+	// either one alone changes both h1 and h2.
+	for _, other := range []Seed{{s.s1 ^ 1, s.s2}, {s.s1, s.s2 ^ 1}} {
+		o1, o2 := other.Sum128(data)
+		if o1 == h1 || o2 == h2 {
+			t.Errorf("seed %x %x: sum %x %x shares a half with %x %x", other.s1, other.s2, o1, o2, h1, h2)
+		}
+	}
+
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Error("zero Seed did not panic")
+			}
+		}()
+		var zero Seed
+		zero.Sum64(data)
+	}()
+}
+
+func TestSeedComparable(t *testing.T) {
+	s := MakeSeed()
+	type key struct {
+		name string
+		id   int32
+		f    float64
+		p    *int
+		i    interface{}
+	}
+	x := 1
+	for _, pair := range [][2]interface{}{
+		{"abc", "abc"},
+		{42, 42},
+		{math.Copysign(0, -1), 0.0},
+		{&x, &x},
+		{[2]string{"a", "bc"}, [2]string{"a", "bc"}},
+		{key{"a", 1, 0, &x, 2}, key{"a", 1, math.Copysign(0, -1), &x, 2}},
+	} {
+		if s.Comparable(pair[0]) != s.Comparable(pair[1]) {
+			t.Errorf("%#v and %#v hash differently", pair[0], pair[1])
+		}
+	}
+	y := 1
+	for _, pair := range [][2]interface{}{
+		{"abc", "abd"},
+		{&x, &y},
+		{[2]string{"a", "bc"}, [2]string{"ab", "c"}},
+		{key{name: "a"}, key{name: "b"}},
+	} {
+		if s.Comparable(pair[0]) == s.Comparable(pair[1]) {
+			t.Errorf("%#v and %#v hash equal", pair[0], pair[1])
+		}
+	}
+
+	for _, v := range []interface{}{[]int{1}, key{i: map[int]int{}}} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("Comparable(%#v) did not panic", v)
+				}
+			}()
+			s.Comparable(v)
+		}()
+	}
+}
+
+func TestSeedMap(t *testing.T) {
+	type point struct{ X, Y int }
+	var m Map
+	if _, ok := m.Get("missing"); ok || m.Len() != 0 {
+		t.Fatal("zero Map not empty")
+	}
+	m.Delete("missing")
+
+	// Mirror random operations on a built in map, across enough keys to
+	// grow the table several times.
+	exp := make(map[interface{}]int)
+	key := func(i int) interface{} {
+		switch i % 4 {
+		case 0:
+			return i
+		case 1:
+			return strconv.Itoa(i)
+		case 2:
+			return point{i, -i}
+		}
+		return float64(i)
+	}
+	var rnd [4]byte
+	for i := 0; i < 5000; i++ {
+		io.ReadFull(rand.Reader, rnd[:])
+		k := key(int(binary.LittleEndian.Uint16(rnd[:])) % 1000)
+		switch rnd[2] % 3 {
+		case 0, 1:
+			m.Set(k, i)
+			exp[k] = i
+		case 2:
+			m.Delete(k)
+			delete(exp, k)
+		}
+	}
+	if m.Len() != len(exp) {
+		t.Errorf("Len = %d, want %d", m.Len(), len(exp))
+	}
+	for i := 0; i < 1000; i++ {
+		k := key(i)
+		v, ok := m.Get(k)
+		e, eok := exp[k]
+		if ok != eok || ok && v.(int) != e {
+			t.Errorf("Get(%#v) = %v, %v; want %v, %v", k, v, ok, e, eok)
+		}
+	}
+	seen := 0
+	m.Range(func(k, v interface{}) bool {
+		if exp[k] != v.(int) {
+			t.Errorf("Range: %#v = %v, want %v", k, v, exp[k])
+		}
+		seen++
+		return true
+	})
+	if seen != len(exp) {
+		t.Errorf("Range visited %d entries, want %d", seen, len(exp))
+	}
+
+	// Equal keys are one key, including the two zeros.
+	var z Map
+	z.Set(0.0, "a")
+	z.Set(math.Copysign(0, -1), "b")
+	if v, _ := z.Get(0.0); z.Len() != 1 || v != "b" {
+		t.Errorf("zeros: Len %d, Get %v", z.Len(), v)
+	}
+}
+
+// TestSeedMulticollision demonstrates the seed independent multicollisions
+// documented on Seed: pairs of 32 byte messages whose mixed blocks differ in
+// bits that cancel out whatever the running hash.
+func TestSeedMulticollision(t *testing.T) {
+	inverse := func(c uint64) uint64 {
+		x := c
+		for i := 0; i < 6; i++ {
+			x *= 2 - c*x
+		}
+		return x
+	}
+	inv1, inv2 := inverse(c1_128), inverse(c2_128)
+	mix1 := func(k uint64) uint64 { return bits.RotateLeft64(k*c1_128, 31) * c2_128 }
+	mix2 := func(k uint64) uint64 { return bits.RotateLeft64(k*c2_128, 33) * c1_128 }
+	unmix1 := func(m uint64) uint64 { return bits.RotateLeft64(m*inv2, -31) * inv1 }
+	unmix2 := func(m uint64) uint64 { return bits.RotateLeft64(m*inv1, -33) * inv2 }
+
+	// A difference of bit 36 in the first mixed k1 is rotated into bit 63 of
+	// both halves. The second block's mixed k1 and k2 then cancel it.
+	const d1, d2 = 1 << 36, 1<<63 | 1<<36
+	pair := func(k [4]uint64) (a, b []byte) {
+		other := [4]uint64{
+			unmix1(mix1(k[0]) ^ d1), k[1],
+			unmix1(mix1(k[2]) ^ d2), unmix2(mix2(k[3]) ^ 1<<63),
+		}
+		for i := range k {
+			a = appendUint64LE(a, k[i])
+			b = appendUint64LE(b, other[i])
+		}
+		return a, b
+	}
+
+	// Three pairs give 2^3 colliding messages of 96 bytes.
+	var pairs [3][2][]byte
+	for i := range pairs {
+		pairs[i][0], pairs[i][1] = pair([4]uint64{uint64(i), 1, 2, 3})
+		if bytes.Equal(pairs[i][0], pairs[i][1]) {
+			t.Fatal("degenerate pair")
+		}
+	}
+	// The last seed is one the canonical implementation can take, to check
+	// the collisions against it.
+	seeds := []Seed{MakeSeed(), MakeSeed(), {0, 1}, {7, 7}}
+	for _, s := range seeds {
+		var want [2]uint64
+		for m := 0; m < 8; m++ {
+			var msg []byte
+			for i := range pairs {
+				msg = append(msg, pairs[i][m>>uint(i)&1]...)
+			}
+			msg = append(msg, "suffix"...)
+			h1, h2 := s.Sum128(msg)
+			if m == 0 {
+				want = [2]uint64{h1, h2}
+			} else if [2]uint64{h1, h2} != want {
+				t.Errorf("message %d: %x %x, want %x", m, h1, h2, want)
+			}
+			if s.s1 != s.s2 || !isLittleEndian {
+				continue
+			}
+			if r1, r2 := testdata.SeedSum128(uint32(s.s1), msg); r1 != h1 || r2 != h2 {
+				t.Errorf("message %d: reference %x %x != %x %x", m, r1, r2, h1, h2)
+			}
+		}
+	}
+}
+
+func TestMarshalBinary(t *testing.T) {
+	type marshalHash interface {
+		hash.Hash
+		encoding.BinaryMarshaler
+		encoding.BinaryUnmarshaler
+	}
+	for _, test := range []struct {
+		name string
+		fn   func() marshalHash
+	}{
+		{"32", func() marshalHash { return SeedNew32(0x8badf00d).(marshalHash) }},
+		{"64", func() marshalHash { return SeedNew64(0xdeadbeef).(marshalHash) }},
+		{"128", func() marshalHash { return SeedNew128(0xcafebabe, 0xfeedface).(marshalHash) }},
+		{"128x86", func() marshalHash { return SeedNew128x86(0xabad1dea).(marshalHash) }},
+	} {
+		for _, elem := range data {
+			for split := 0; split <= len(elem.s); split++ {
+				h := test.fn()
+				h.Write([]byte(elem.s))
+				want := h.Sum(nil)
+
+				h.Reset()
+				h.Write([]byte(elem.s[:split]))
+				state, err := h.MarshalBinary()
+				if err != nil {
+					t.Fatalf("%s: unable to marshal: %v", test.name, err)
+				}
+
+				resumed := test.fn()
+				resumed.Reset()
+				resumed.Write([]byte("garbage"))
+				if err := resumed.UnmarshalBinary(state); err != nil {
+					t.Fatalf("%s: unable to unmarshal: %v", test.name, err)
+				}
+				resumed.Write([]byte(elem.s[split:]))
+				if got := resumed.Sum(nil); !bytes.Equal(got, want) {
+					t.Errorf("%s: '%s' split at %d: got %x != exp %x", test.name, elem.s, split, got, want)
+				}
+			}
+		}
+	}
+
+	// The seed is part of the state.
+	h := SeedNew128(1, 2)
+	state, _ := h.(encoding.BinaryMarshaler).MarshalBinary()
+	resumed := New128()
+	if err := resumed.(encoding.BinaryUnmarshaler).UnmarshalBinary(state); err != nil {
+		t.Fatalf("unable to unmarshal: %v", err)
+	}
+	resumed.Reset()
+	resumed.Write([]byte("hello"))
+	got1, got2 := resumed.Sum128()
+	exp1, exp2 := SeedSum128(1, 2, []byte("hello"))
+	if got1 != exp1 || got2 != exp2 {
+		t.Errorf("seed not restored: got %x-%x != exp %x-%x", got1, got2, exp1, exp2)
+	}
+
+	// States do not cross digest kinds and must be complete.
+	state, _ = New64().(encoding.BinaryMarshaler).MarshalBinary()
+	if err := New128().(encoding.BinaryUnmarshaler).UnmarshalBinary(state); err != errStateIdentifier {
+		t.Errorf("unmarshal 64 state into 128: got err %v != exp %v", err, errStateIdentifier)
+	}
+	if err := New64().(encoding.BinaryUnmarshaler).UnmarshalBinary(state[:len(state)-1]); err != errStateSize {
+		t.Errorf("unmarshal short state: got err %v != exp %v", err, errStateSize)
+	}
+
+	// Corrupt lengths and padding are rejected rather than panicking later.
+	for _, h := range []hash.Hash{New32(), New64(), New128(), New128x86()} {
+		h.Write([]byte("abc"))
+		state, _ := h.(encoding.BinaryMarshaler).MarshalBinary()
+		clen := len(state) - 8 - h.Size()
+		for _, corrupt := range []func(b []byte){
+			func(b []byte) { b[clen] = 0x80 },
+			func(b []byte) { b[clen+7] = 1 },
+			func(b []byte) { b[len(b)-1] = 1 },
+		} {
+			bad := append([]byte(nil), state...)
+			corrupt(bad)
+			if err := h.(encoding.BinaryUnmarshaler).UnmarshalBinary(bad); err != errStateSize {
+				t.Errorf("%T: unmarshal corrupt state: got err %v != exp %v", h, err, errStateSize)
+			}
+		}
+		if err := h.(encoding.BinaryUnmarshaler).UnmarshalBinary(state); err != nil {
+			t.Errorf("%T: unmarshal valid state: %v", h, err)
+		}
+	}
+}
+
+func TestBatch(t *testing.T) {
+	// Small batches, and batches spanning several chunks.
+	for _, nkeys := range []int{0, 1, 2, 3, 4, 5, 6, 7, batchChunk + 1, 3*batchChunk + 7} {
+		for i := 0; i < 20; i++ {
+			keys := make([][]byte, nkeys)
+			skeys := make([]string, nkeys)
+			for j := range keys {
+				var lenbuf [1]byte
+				io.ReadFull(rand.Reader, lenbuf[:])
+				keys[j] = make([]byte, int(lenbuf[0])%70)
+				io.ReadFull(rand.Reader, keys[j])
+				skeys[j] = string(keys[j])
+			}
+			const seed = 0x1234
+
+			out32 := make([]uint32, nkeys)
+			sout32 := make([]uint32, nkeys)
+			Sum32Batch(seed, keys, out32)
+			StringSum32Batch(seed, skeys, sout32)
+
+			out64 := make([]uint64, nkeys)
+			sout64 := make([]uint64, nkeys)
+			Sum64Batch(seed, keys, out64)
+			StringSum64Batch(seed, skeys, sout64)
+
+			out128 := make([][2]uint64, nkeys)
+			sout128 := make([][2]uint64, nkeys)
+			Sum128Batch(seed, seed+1, keys, out128)
+			StringSum128Batch(seed, seed+1, skeys, sout128)
+
+			for j, key := range keys {
+				if exp := SeedSum32(seed, key); out32[j] != exp || sout32[j] != exp {
+					t.Errorf("Sum32Batch #%d of %d: got %x, string %x != exp %x", j, nkeys, out32[j], sout32[j], exp)
+				}
+				if exp := SeedSum64(seed, key); out64[j] != exp || sout64[j] != exp {
+					t.Errorf("Sum64Batch #%d of %d: got %x, string %x != exp %x", j, nkeys, out64[j], sout64[j], exp)
+				}
+				exp1, exp2 := SeedSum128(seed, seed+1, key)
+				if exp := [2]uint64{exp1, exp2}; out128[j] != exp || sout128[j] != exp {
+					t.Errorf("Sum128Batch #%d of %d: got %x, string %x != exp %x", j, nkeys, out128[j], sout128[j], exp)
+				}
+			}
+		}
+	}
+}
+
+func TestUint(t *testing.T) {
+	var b [8]byte
+	if err := quick.Check(func(seed, v uint32) bool {
+		binary.LittleEndian.PutUint32(b[:], v)
+		return SeedSumUint32(seed, v) == SeedSum32(seed, b[:4]) &&
+			SumUint32(v) == Sum32(b[:4])
+	}, nil); err != nil {
+		t.Error(err)
+	}
+	if err := quick.Check(func(seed1, seed2, v uint64) bool {
+		binary.LittleEndian.PutUint64(b[:], v)
+		h1, h2 := SeedSum128Uint64(seed1, seed2, v)
+		exp1, exp2 := SeedSum128(seed1, seed2, b[:])
+		s1, s2 := Sum128Uint64(v)
+		z1, z2 := Sum128(b[:])
+		return h1 == exp1 && h2 == exp2 && s1 == z1 && s2 == z2 &&
+			SeedSumUint64(seed1, v) == SeedSum64(seed1, b[:]) &&
+			SumUint64(v) == Sum64(b[:])
+	}, nil); err != nil {
+		t.Error(err)
+	}
+
+	if n := testing.AllocsPerRun(100, func() {
+		SumUint32(1)
+		SumUint64(1)
+		Sum128Uint64(1)
+	}); n != 0 {
+		t.Errorf("integer sums allocated %v times", n)
+	}
+}
+
+func TestMixPrimitives(t *testing.T) {
+	if err := quick.Check(func(h uint32, k uint64) bool {
+		exp32, exp64 := Fmix32(h), Fmix64(k)
+		if isLittleEndian {
+			exp32, exp64 = testdata.Fmix32(h), testdata.Fmix64(k)
+		}
+		return Fmix32(h) == exp32 && Fmix64(k) == exp64
+	}, nil); err != nil {
+		t.Error(err)
+	}
+
+	// The documented compositions reproduce the reference sums of whole
+	// blocks.
+	if err := quick.Check(func(seed uint32, data []byte) bool {
+		data = data[:len(data)&^15]
+		exp32 := SeedSum32(seed, data)
+		exp1, exp2 := SeedSum128(uint64(seed), uint64(seed), data)
+		if isLittleEndian {
+			exp32 = testdata.SeedSum32(seed, data)
+			exp1, exp2 = testdata.SeedSum128(seed, data)
+		}
+
+		h := seed
+		for b := data; len(b) > 0; b = b[4:] {
+			h = Mix32Block(h, binary.LittleEndian.Uint32(b))
+		}
+		if Fmix32(h^uint32(len(data))) != exp32 {
+			return false
+		}
+
+		h1, h2 := uint64(seed), uint64(seed)
+		for b := data; len(b) > 0; b = b[16:] {
+			h1, h2 = Mix128Block(h1, h2, binary.LittleEndian.Uint64(b), binary.LittleEndian.Uint64(b[8:]))
+		}
+		h1 ^= uint64(len(data))
+		h2 ^= uint64(len(data))
+		h1 += h2
+		h2 += h1
+		h1, h2 = Fmix64(h1), Fmix64(h2)
+		h1 += h2
+		h2 += h1
+		return h1 == exp1 && h2 == exp2
+	}, nil); err != nil {
+		t.Error(err)
+	}
+
+	if Fmix32(0) != 0 || Fmix64(0) != 0 {
+		t.Error("finalizers do not map zero to zero")
 	}
 }
 
@@ -393,6 +1127,9 @@ func Benchmark64Sizes(b *testing.B) {
 	}
 }
 
+// Benchmark128Sizes measures the assembly on amd64, or on arm64 with
+// -tags murmur3_arm64asm; run it again with -tags purego to measure the
+// generic code on the same machine.
 func Benchmark128Sizes(b *testing.B) {
 	buf := make([]byte, 8192)
 	for length := 32; length <= cap(buf); length *= 2 {
@@ -407,6 +1144,45 @@ func Benchmark128Sizes(b *testing.B) {
 	}
 }
 
+func Benchmark128x86Sizes(b *testing.B) {
+	buf := make([]byte, 8192)
+	for length := 32; length <= cap(buf); length *= 2 {
+		b.Run(strconv.Itoa(length), func(b *testing.B) {
+			buf = buf[:length]
+			b.SetBytes(int64(length))
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				Sum128x86(buf)
+			}
+		})
+	}
+}
+
+func Benchmark128Batch(b *testing.B) {
+	for _, length := range []int{8, 16, 32, 64} {
+		keys := make([][]byte, 1024)
+		for i := range keys {
+			keys[i] = make([]byte, length)
+			keys[i][0] = byte(i)
+		}
+		out := make([][2]uint64, len(keys))
+		b.Run(strconv.Itoa(length)+"/loop", func(b *testing.B) {
+			b.SetBytes(int64(length * len(keys)))
+			for i := 0; i < b.N; i++ {
+				for j, key := range keys {
+					out[j][0], out[j][1] = Sum128(key)
+				}
+			}
+		})
+		b.Run(strconv.Itoa(length)+"/batch", func(b *testing.B) {
+			b.SetBytes(int64(length * len(keys)))
+			for i := 0; i < b.N; i++ {
+				Sum128Batch(0, 0, keys, out)
+			}
+		})
+	}
+}
+
 func BenchmarkNoescape32(b *testing.B) {
 	for i := 0; i < b.N; i++ {
 		var buf [8192]byte
@@ -420,3 +1196,81 @@ func BenchmarkNoescape128(b *testing.B) {
 		Sum128(buf[:])
 	}
 }
+
+func BenchmarkSumUint64(b *testing.B) {
+	b.Run("bytes", func(b *testing.B) {
+		var buf [8]byte
+		for i := 0; i < b.N; i++ {
+			binary.LittleEndian.PutUint64(buf[:], uint64(i))
+			Sum64(buf[:])
+		}
+	})
+	b.Run("uint64", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			SumUint64(uint64(i))
+		}
+	})
+}
+
+// onlyReader hides any io.WriterTo of the underlying reader, so that io.Copy
+// uses the destination's io.ReaderFrom, or its own buffer without one.
+type onlyReader struct{ io.Reader }
+
+// onlyWriter hides the hasher's io.ReaderFrom from io.Copy.
+type onlyWriter struct{ io.Writer }
+
+func BenchmarkCopy128(b *testing.B) {
+	data := make([]byte, 1<<20)
+	b.Run("write", func(b *testing.B) {
+		b.SetBytes(int64(len(data)))
+		h := New128()
+		for i := 0; i < b.N; i++ {
+			io.Copy(onlyWriter{h}, onlyReader{bytes.NewReader(data)})
+		}
+	})
+	b.Run("readfrom", func(b *testing.B) {
+		b.SetBytes(int64(len(data)))
+		h := New128()
+		for i := 0; i < b.N; i++ {
+			io.Copy(h, onlyReader{bytes.NewReader(data)})
+		}
+	})
+}
+
+func BenchmarkWriteString128(b *testing.B) {
+	for _, length := range []int{8, 64, 1024} {
+		s := string(make([]byte, length))
+		b.Run(strconv.Itoa(length)+"/write", func(b *testing.B) {
+			b.SetBytes(int64(length))
+			h := New128()
+			for i := 0; i < b.N; i++ {
+				h.Write([]byte(s))
+			}
+		})
+		b.Run(strconv.Itoa(length)+"/writestring", func(b *testing.B) {
+			b.SetBytes(int64(length))
+			h := New128().(stringWriter)
+			for i := 0; i < b.N; i++ {
+				h.WriteString(s)
+			}
+		})
+	}
+}
+
+func BenchmarkTreeSum128(b *testing.B) {
+	data := make([]byte, 64<<20)
+	b.Run("sum128", func(b *testing.B) {
+		b.SetBytes(int64(len(data)))
+		for i := 0; i < b.N; i++ {
+			Sum128(data)
+		}
+	})
+	for _, workers := range []int{1, 4, 0} {
+		b.Run("tree/"+strconv.Itoa(workers), func(b *testing.B) {
+			b.SetBytes(int64(len(data)))
+			for i := 0; i < b.N; i++ {
+				TreeSum128(data, 0, workers)
+			}
+		})
+	}
+}
@@ -0,0 +1,67 @@
+package murmur3
+
+import "math/bits"
+
+// SumUint32 returns Sum32 of the four little endian bytes of v, without
+// encoding v.
+func SumUint32(v uint32) uint32 {
+	return SeedSumUint32(0, v)
+}
+
+// SeedSumUint32 returns SeedSum32(seed, ...) of the four little endian bytes
+// of v, without encoding v.
+func SeedSumUint32(seed, v uint32) uint32 {
+	k1 := v * c1_32
+	k1 = bits.RotateLeft32(k1, 15)
+	k1 *= c2_32
+
+	h1 := seed ^ k1
+	h1 = bits.RotateLeft32(h1, 13)
+	h1 = h1*5 + 0xe6546b64
+
+	h1 ^= 4
+	return Fmix32(h1)
+}
+
+// SumUint64 returns Sum64 of the eight little endian bytes of v, without
+// encoding v.
+func SumUint64(v uint64) uint64 {
+	h1, _ := SeedSum128Uint64(0, 0, v)
+	return h1
+}
+
+// SeedSumUint64 returns SeedSum64(seed, ...) of the eight little endian bytes
+// of v, without encoding v.
+func SeedSumUint64(seed, v uint64) uint64 {
+	h1, _ := SeedSum128Uint64(seed, seed, v)
+	return h1
+}
+
+// Sum128Uint64 returns Sum128 of the eight little endian bytes of v, without
+// encoding v.
+func Sum128Uint64(v uint64) (h1 uint64, h2 uint64) {
+	return SeedSum128Uint64(0, 0, v)
+}
+
+// SeedSum128Uint64 returns SeedSum128(seed1, seed2, ...) of the eight little
+// endian bytes of v, without encoding v.
+func SeedSum128Uint64(seed1, seed2, v uint64) (h1 uint64, h2 uint64) {
+	// Eight bytes are all tail: they only ever reach k1.
+	k1 := v * c1_128
+	k1 = bits.RotateLeft64(k1, 31)
+	k1 *= c2_128
+
+	h1 = seed1 ^ k1 ^ 8
+	h2 = seed2 ^ 8
+
+	h1 += h2
+	h2 += h1
+
+	h1 = Fmix64(h1)
+	h2 = Fmix64(h2)
+
+	h1 += h2
+	h2 += h1
+
+	return h1, h2
+}
@@ -0,0 +1,179 @@
+// Package simhash computes 64 bit SimHash fingerprints, whose Hamming
+// distances approximate the dissimilarity of the documents they fingerprint,
+// and indexes them to find all fingerprints within a few bits of a query.
+//
+// Features are hashed with murmur3.StringSum64. The index follows Manku,
+// Jain and Das Sarma ("Detecting Near-Duplicates for Web Crawling", 2007):
+// fingerprints within k bits of each other agree exactly on at least one of
+// k+1 blocks of their bits, so the index keeps k+1 sorted tables, each
+// permuted so that one block leads, and searches each by that block.
+package simhash
+
+import (
+	"math/bits"
+	"sort"
+
+	"github.com/twmb/murmur3"
+)
+
+// Feature is a weighted feature of a document, such as a word or shingle and
+// its frequency.
+type Feature struct {
+	Text   string
+	Weight float64
+}
+
+// Fingerprint returns the SimHash of the weighted features: bit i is set if
+// the total weight of features whose hash sets bit i exceeds that of features
+// whose hash does not.
+func Fingerprint(features []Feature) uint64 {
+	var v [64]float64
+	for _, f := range features {
+		h := murmur3.StringSum64(f.Text)
+		for i := range v {
+			if h&(1<<uint(i)) != 0 {
+				v[i] += f.Weight
+			} else {
+				v[i] -= f.Weight
+			}
+		}
+	}
+	return fold(&v)
+}
+
+// FingerprintStrings returns the SimHash of features that each have a weight
+// of one. Repeated features count once per occurrence.
+func FingerprintStrings(features []string) uint64 {
+	var v [64]float64
+	for _, f := range features {
+		h := murmur3.StringSum64(f)
+		for i := range v {
+			if h&(1<<uint(i)) != 0 {
+				v[i]++
+			} else {
+				v[i]--
+			}
+		}
+	}
+	return fold(&v)
+}
+
+func fold(v *[64]float64) uint64 {
+	var fp uint64
+	for i, w := range v {
+		if w > 0 {
+			fp |= 1 << uint(i)
+		}
+	}
+	return fp
+}
+
+// Distance returns the number of bits in which a and b differ.
+func Distance(a, b uint64) int { return bits.OnesCount64(a ^ b) }
+
+// Index finds stored fingerprints within k bits of a query. It stores each
+// fingerprint k+1 times, once per table.
+//
+// Adding is cheap; tables are sorted on the next query. An Index is not safe
+// for concurrent use, including concurrent queries.
+type Index struct {
+	k      int
+	tables []table
+	sorted bool
+}
+
+// table holds fingerprints rotated so that one block occupies the top width
+// bits.
+type table struct {
+	rot   uint
+	width uint
+	fps   []uint64
+}
+
+// NewIndex returns an empty index answering queries within k bits. K is
+// clamped to [0, 63].
+func NewIndex(k int) *Index {
+	if k < 0 {
+		k = 0
+	}
+	if k > 63 {
+		k = 63
+	}
+	blocks := k + 1
+	ix := &Index{k: k, tables: make([]table, blocks), sorted: true}
+	end := 64
+	for i := range ix.tables {
+		// Spread the remainder over the first blocks, which start at
+		// the top of the fingerprint.
+		width := 64 / blocks
+		if i < 64%blocks {
+			width++
+		}
+		ix.tables[i] = table{rot: uint(64 - end), width: uint(width)}
+		end -= width
+	}
+	return ix
+}
+
+// K returns the number of differing bits the index answers queries within.
+func (ix *Index) K() int { return ix.k }
+
+// Len returns the number of fingerprints added.
+func (ix *Index) Len() int { return len(ix.tables[0].fps) }
+
+// Add adds fp to the index. Fingerprints added more than once are returned
+// more than once by Query.
+func (ix *Index) Add(fp uint64) {
+	for i := range ix.tables {
+		t := &ix.tables[i]
+		t.fps = append(t.fps, bits.RotateLeft64(fp, int(t.rot)))
+	}
+	ix.sorted = false
+}
+
+func (ix *Index) sort() {
+	if ix.sorted {
+		return
+	}
+	for _, t := range ix.tables {
+		fps := t.fps
+		sort.Slice(fps, func(i, j int) bool { return fps[i] < fps[j] })
+	}
+	ix.sorted = true
+}
+
+// Query returns all added fingerprints within k bits of fp, sorted.
+func (ix *Index) Query(fp uint64) []uint64 {
+	ix.sort()
+	var found []uint64
+	for ti, t := range ix.tables {
+		q := bits.RotateLeft64(fp, int(t.rot))
+		shift := 64 - t.width
+		prefix := q >> shift
+		i := sort.Search(len(t.fps), func(i int) bool { return t.fps[i]>>shift >= prefix })
+		for ; i < len(t.fps) && t.fps[i]>>shift == prefix; i++ {
+			if Distance(q, t.fps[i]) > ix.k {
+				continue
+			}
+			// A match agreeing on an earlier block has already been
+			// found through that block's table.
+			cand := bits.RotateLeft64(t.fps[i], -int(t.rot))
+			if !ix.matchesEarlier(fp, cand, ti) {
+				found = append(found, cand)
+			}
+		}
+	}
+	sort.Slice(found, func(i, j int) bool { return found[i] < found[j] })
+	return found
+}
+
+// matchesEarlier returns whether a and b agree on any block before block n.
+func (ix *Index) matchesEarlier(a, b uint64, n int) bool {
+	for _, t := range ix.tables[:n] {
+		shift := 64 - t.width
+		if bits.RotateLeft64(a, int(t.rot))>>shift == bits.RotateLeft64(b, int(t.rot))>>shift {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,115 @@
+package simhash
+
+import (
+	"math/rand"
+	"strings"
+	"testing"
+)
+
+func TestFingerprint(t *testing.T) {
+	base := strings.Fields("the quick brown fox jumps over the lazy dog while the cat sleeps in the warm afternoon sun")
+	near := append(append([]string(nil), base...), "today")
+	far := strings.Fields("lorem ipsum dolor sit amet consectetur adipiscing elit sed do eiusmod tempor incididunt")
+
+	a, b, c := FingerprintStrings(base), FingerprintStrings(near), FingerprintStrings(far)
+	if dn, df := Distance(a, b), Distance(a, c); dn >= df || dn > 10 {
+		t.Errorf("near distance %d, far distance %d", dn, df)
+	}
+
+	// Unit weights match FingerprintStrings, and a heavy feature dominates.
+	features := make([]Feature, len(base))
+	for i, f := range base {
+		features[i] = Feature{f, 1}
+	}
+	if got := Fingerprint(features); got != a {
+		t.Errorf("unit weights: %016x != %016x", got, a)
+	}
+	features = append(features, Feature{"anchor", 1000})
+	if got, exp := Fingerprint(features), Fingerprint([]Feature{{"anchor", 1}}); got != exp {
+		t.Errorf("dominant feature: %016x != %016x", got, exp)
+	}
+
+	if FingerprintStrings(nil) != 0 {
+		t.Error("fingerprint of no features is not zero")
+	}
+}
+
+func TestDistance(t *testing.T) {
+	for _, test := range []struct {
+		a, b uint64
+		exp  int
+	}{
+		{0, 0, 0},
+		{0, 1, 1},
+		{0xff00, 0x00ff, 16},
+		{0, 0xffffffffffffffff, 64},
+	} {
+		if got := Distance(test.a, test.b); got != test.exp {
+			t.Errorf("Distance(%x, %x): got %d != exp %d", test.a, test.b, got, test.exp)
+		}
+	}
+}
+
+func TestIndex(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	for _, k := range []int{0, 1, 3, 6} {
+		ix := NewIndex(k)
+		var all []uint64
+		for i := 0; i < 5000; i++ {
+			fp := rng.Uint64()
+			all = append(all, fp)
+			// Plant neighbors at and just beyond k bits.
+			for d := 1; d <= k+1; d++ {
+				near := fp
+				for _, bit := range rng.Perm(64)[:d] {
+					near ^= 1 << uint(bit)
+				}
+				all = append(all, near)
+			}
+		}
+		for _, fp := range all {
+			ix.Add(fp)
+		}
+		if ix.Len() != len(all) {
+			t.Fatalf("k %d: Len %d != %d", k, ix.Len(), len(all))
+		}
+
+		for _, q := range all[:500] {
+			var exp []uint64
+			for _, fp := range all {
+				if Distance(q, fp) <= k {
+					exp = append(exp, fp)
+				}
+			}
+			got := ix.Query(q)
+			if len(got) != len(exp) {
+				t.Fatalf("k %d, query %016x: got %d matches, want %d", k, q, len(got), len(exp))
+			}
+			seen := make(map[uint64]int)
+			for _, fp := range exp {
+				seen[fp]++
+			}
+			for _, fp := range got {
+				seen[fp]--
+			}
+			for fp, n := range seen {
+				if n != 0 {
+					t.Fatalf("k %d, query %016x: match %016x count off by %d", k, q, fp, n)
+				}
+			}
+		}
+	}
+}
+
+func BenchmarkQuery(b *testing.B) {
+	rng := rand.New(rand.NewSource(1))
+	ix := NewIndex(3)
+	for i := 0; i < 1000000; i++ {
+		ix.Add(rng.Uint64())
+	}
+	ix.Query(0)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		ix.Query(rng.Uint64())
+	}
+}
@@ -0,0 +1,161 @@
+// Package spark reproduces Apache Spark's Murmur3Hash expression, the hash
+// behind Spark SQL's hash function and its bucketed tables, so that rows
+// bucketed in Go land in the same buckets Spark expects.
+//
+// Spark hashes each column with murmur3 x86_32, using the running hash of the
+// preceding columns as the seed, starting from Seed. Values are encoded by
+// type: ints as their four little endian bytes, longs as eight, and strings
+// and binaries as their bytes, except that Spark mixes each trailing byte of
+// a string or binary as a whole sign extended block rather than as part of a
+// final partial block. That makes the hash of such values differ from
+// murmur3.SeedSum32 whenever their length is not a multiple of four.
+package spark
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/twmb/murmur3"
+)
+
+// Seed is the seed Spark hashes the first column of a row with.
+const Seed = 42
+
+// HashInt returns the hash of a Spark IntegerType value. ByteType,
+// ShortType and DateType values, the latter as days since the epoch, hash as
+// their int value.
+func HashInt(v int32, seed int32) int32 {
+	h1 := murmur3.Mix32Block(uint32(seed), uint32(v))
+	return int32(murmur3.Fmix32(h1 ^ 4))
+}
+
+// HashLong returns the hash of a Spark LongType value. TimestampType values
+// hash as their microseconds since the epoch.
+func HashLong(v int64, seed int32) int32 {
+	h1 := murmur3.Mix32Block(uint32(seed), uint32(v))
+	h1 = murmur3.Mix32Block(h1, uint32(uint64(v)>>32))
+	return int32(murmur3.Fmix32(h1 ^ 8))
+}
+
+// HashBool returns the hash of a Spark BooleanType value, the int 1 or 0.
+func HashBool(v bool, seed int32) int32 {
+	if v {
+		return HashInt(1, seed)
+	}
+	return HashInt(0, seed)
+}
+
+// HashFloat returns the hash of a Spark FloatType value: the int of its bits,
+// with negative zero hashing as zero and every NaN as Java's canonical NaN.
+func HashFloat(v float32, seed int32) int32 {
+	switch {
+	case v == 0:
+		return HashInt(0, seed)
+	case v != v:
+		return HashInt(0x7fc00000, seed)
+	}
+	return HashInt(int32(math.Float32bits(v)), seed)
+}
+
+// HashDouble returns the hash of a Spark DoubleType value: the long of its
+// bits, with negative zero hashing as zero and every NaN as Java's canonical
+// NaN.
+func HashDouble(v float64, seed int32) int32 {
+	switch {
+	case v == 0:
+		return HashLong(0, seed)
+	case v != v:
+		return HashLong(0x7ff8000000000000, seed)
+	}
+	return HashLong(int64(math.Float64bits(v)), seed)
+}
+
+// HashString returns the hash of a Spark StringType value, hashing the UTF-8
+// of s.
+func HashString(s string, seed int32) int32 {
+	h1 := uint32(seed)
+	i := 0
+	for ; i+4 <= len(s); i += 4 {
+		k1 := uint32(s[i]) | uint32(s[i+1])<<8 | uint32(s[i+2])<<16 | uint32(s[i+3])<<24
+		h1 = murmur3.Mix32Block(h1, k1)
+	}
+	for ; i < len(s); i++ {
+		h1 = murmur3.Mix32Block(h1, uint32(int8(s[i])))
+	}
+	return int32(murmur3.Fmix32(h1 ^ uint32(len(s))))
+}
+
+// HashBinary returns the hash of a Spark BinaryType value.
+func HashBinary(b []byte, seed int32) int32 {
+	h1 := uint32(seed)
+	i := 0
+	for ; i+4 <= len(b); i += 4 {
+		k1 := uint32(b[i]) | uint32(b[i+1])<<8 | uint32(b[i+2])<<16 | uint32(b[i+3])<<24
+		h1 = murmur3.Mix32Block(h1, k1)
+	}
+	for ; i < len(b); i++ {
+		h1 = murmur3.Mix32Block(h1, uint32(int8(b[i])))
+	}
+	return int32(murmur3.Fmix32(h1 ^ uint32(len(b))))
+}
+
+// HashValue returns the hash of a single column value, chosen by its Go type:
+//
+//	nil                      null, which leaves seed unchanged
+//	bool                     BooleanType
+//	int8, int16, int32       ByteType, ShortType, IntegerType
+//	int, int64               LongType
+//	float32, float64         FloatType, DoubleType
+//	string, []byte           StringType, BinaryType
+//	[]interface{}            ArrayType or StructType, hashing each element
+//	                         with the running hash as its seed
+//
+// HashValue panics on any other type.
+func HashValue(v interface{}, seed int32) int32 {
+	switch v := v.(type) {
+	case nil:
+		return seed
+	case bool:
+		return HashBool(v, seed)
+	case int8:
+		return HashInt(int32(v), seed)
+	case int16:
+		return HashInt(int32(v), seed)
+	case int32:
+		return HashInt(v, seed)
+	case int:
+		return HashLong(int64(v), seed)
+	case int64:
+		return HashLong(v, seed)
+	case float32:
+		return HashFloat(v, seed)
+	case float64:
+		return HashDouble(v, seed)
+	case string:
+		return HashString(v, seed)
+	case []byte:
+		return HashBinary(v, seed)
+	case []interface{}:
+		for _, e := range v {
+			seed = HashValue(e, seed)
+		}
+		return seed
+	}
+	panic(fmt.Sprintf("spark: cannot hash value of type %T", v))
+}
+
+// HashRow returns the hash of a row of column values, as Spark's
+// hash(col1, col2, ...). See HashValue for how Go types map to Spark types.
+func HashRow(values ...interface{}) int32 {
+	return HashValue(values, Seed)
+}
+
+// Bucket returns the bucket of a row hash among numBuckets, Spark's
+// pmod(hash, numBuckets).
+func Bucket(hash int32, numBuckets int) int {
+	b := int(hash) % numBuckets
+	if b < 0 {
+		b += numBuckets
+	}
+	return b
+}
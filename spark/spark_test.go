@@ -0,0 +1,122 @@
+package spark
+
+import (
+	"encoding/binary"
+	"math"
+	"testing"
+	"testing/quick"
+
+	"github.com/twmb/murmur3"
+)
+
+func TestHashRow(t *testing.T) {
+	for _, test := range []struct {
+		name string
+		row  []interface{}
+		exp  int32
+	}{
+		// From Spark's documentation of the hash function.
+		{"hash('Spark', array(123), 2)", []interface{}{"Spark", []interface{}{int32(123)}, int32(2)}, -1321691492},
+		{"hash(1)", []interface{}{int32(1)}, -559580957},
+		{"hash(null)", []interface{}{nil}, 42},
+		{"hash()", nil, 42},
+	} {
+		if got := HashRow(test.row...); got != test.exp {
+			t.Errorf("%s: got %d != exp %d", test.name, got, test.exp)
+		}
+	}
+}
+
+func TestCanonical(t *testing.T) {
+	// Ints, longs and strings of whole blocks hash as the canonical
+	// x86_32 over their little endian encoding.
+	var b [8]byte
+	if err := quick.Check(func(v int32, seed int32) bool {
+		binary.LittleEndian.PutUint32(b[:], uint32(v))
+		return HashInt(v, seed) == int32(murmur3.SeedSum32(uint32(seed), b[:4]))
+	}, nil); err != nil {
+		t.Error(err)
+	}
+	if err := quick.Check(func(v int64, seed int32) bool {
+		binary.LittleEndian.PutUint64(b[:], uint64(v))
+		return HashLong(v, seed) == int32(murmur3.SeedSum32(uint32(seed), b[:]))
+	}, nil); err != nil {
+		t.Error(err)
+	}
+	if err := quick.Check(func(data []byte, seed int32) bool {
+		data = data[:len(data)&^3]
+		exp := int32(murmur3.SeedSum32(uint32(seed), data))
+		return HashBinary(data, seed) == exp && HashString(string(data), seed) == exp
+	}, nil); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestTail(t *testing.T) {
+	// Each tail byte is its own sign extended block, so "a" is not the
+	// canonical hash of "a" but that of the int 'a' with a length of one.
+	if got, exp := HashString("a", Seed), int32(murmur3.Fmix32(murmur3.Mix32Block(Seed, 'a')^1)); got != exp {
+		t.Errorf("HashString(a): got %d != exp %d", got, exp)
+	}
+	if HashString("a", Seed) == int32(murmur3.SeedStringSum32(Seed, "a")) {
+		t.Error("HashString(a) unexpectedly matches the canonical hash")
+	}
+	if got, exp := HashBinary([]byte{0xff}, Seed), int32(murmur3.Fmix32(murmur3.Mix32Block(Seed, 0xffffffff)^1)); got != exp {
+		t.Errorf("HashBinary(0xff): got %d != exp %d", got, exp)
+	}
+	if err := quick.Check(func(s string, seed int32) bool {
+		return HashString(s, seed) == HashBinary([]byte(s), seed)
+	}, nil); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestHashValue(t *testing.T) {
+	const seed = 7
+	for _, test := range []struct {
+		v   interface{}
+		exp int32
+	}{
+		{true, HashInt(1, seed)},
+		{false, HashInt(0, seed)},
+		{int8(-3), HashInt(-3, seed)},
+		{int16(300), HashInt(300, seed)},
+		{int(5), HashLong(5, seed)},
+		{float32(1.5), HashInt(int32(math.Float32bits(1.5)), seed)},
+		{float32(math.Copysign(0, -1)), HashInt(0, seed)},
+		{float32(math.NaN()), HashInt(0x7fc00000, seed)},
+		{2.5, HashLong(int64(math.Float64bits(2.5)), seed)},
+		{math.Copysign(0, -1), HashLong(0, seed)},
+		{math.NaN(), HashLong(0x7ff8000000000000, seed)},
+		{[]interface{}{nil, "x", nil}, HashString("x", seed)},
+	} {
+		if got := HashValue(test.v, seed); got != test.exp {
+			t.Errorf("HashValue(%#v): got %d != exp %d", test.v, got, test.exp)
+		}
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("HashValue of an unsupported type did not panic")
+		}
+	}()
+	HashValue(uint32(1), seed)
+}
+
+func TestBucket(t *testing.T) {
+	for _, test := range []struct {
+		hash    int32
+		buckets int
+		exp     int
+	}{
+		{0, 8, 0},
+		{13, 8, 5},
+		{-13, 8, 3},
+		{-559580957, 16, 3},
+		{math.MinInt32, 7, 5},
+	} {
+		if got := Bucket(test.hash, test.buckets); got != test.exp {
+			t.Errorf("Bucket(%d, %d): got %d != exp %d", test.hash, test.buckets, got, test.exp)
+		}
+	}
+}
@@ -0,0 +1,438 @@
+// Package structhash hashes Go values with murmur3 by walking them with
+// reflection, rather than hashing a formatted string of them.
+//
+// Values are written in a canonical, type tagged, little endian encoding to a
+// murmur3.SeedNew128 digest. Every value starts with a one byte tag naming its
+// kind, so that, say, int64(1), uint64(1), "1" and true all hash differently,
+// while the encoding does not depend on the platform, on map iteration order
+// or on the location of a time.Time. Tags name kinds of values rather than
+// types: int8(1) and int64(1) hash equal, as do a slice other than []byte and
+// an array with the same elements.
+//
+// The encoding is:
+//
+//   - bools are one byte; integers of every size are 8 bytes, signed kinds
+//     sign extended; floats are their IEEE 754 bits with -0 written as 0 and
+//     every NaN written as the same NaN, float32 in 4 bytes and float64 in 8;
+//     complex numbers are their real and then imaginary parts.
+//   - strings and []byte are their 8 byte length and their bytes.
+//   - arrays and slices are their length and then each element. Slices and
+//     arrays hashed as sets are their length and the 16 byte
+//     murmur3.MultisetHash128 sum of their encoded elements.
+//   - maps are their length and then each key and value, in the byte order
+//     of the encoded keys.
+//   - structs are their number of hashed fields and then, in declaration
+//     order, each field name as a string and the field value. Unexported
+//     fields are skipped.
+//   - time.Time is its Unix seconds and nanoseconds, so equal instants in
+//     different locations hash equal.
+//   - pointers and interfaces are the value they point to or hold; nil
+//     pointers, interfaces, maps and slices are a lone nil tag.
+//
+// Channels, functions and unsafe pointers cannot be hashed, nor can values
+// that refer back to themselves.
+//
+// Struct fields can be tuned with a `murmur3` tag: `murmur3:"-"` skips the
+// field, and `murmur3:",set"` hashes a slice or array field without regard to
+// the order of its elements. Options.Sets does the same for every slice and
+// array.
+package structhash
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/twmb/murmur3"
+)
+
+// TagName is the struct tag key read for field options.
+const TagName = "murmur3"
+
+// Type tags starting every encoded value. These are part of the encoding and
+// must never be renumbered.
+const (
+	tagNil byte = iota
+	tagBool
+	tagInt
+	tagUint
+	tagFloat32
+	tagFloat64
+	tagComplex64
+	tagComplex128
+	tagString
+	tagBytes
+	tagArray
+	tagSet
+	tagMap
+	tagStruct
+	tagTime
+)
+
+var errCycle = errors.New("structhash: value refers to itself")
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// Options configures hashing. The zero value hashes with seeds of zero and
+// hashes slices and arrays in order.
+type Options struct {
+	// Seed1 and Seed2 seed the murmur3.SeedNew128 digest.
+	Seed1, Seed2 uint64
+
+	// Sets, if true, hashes every slice and array without regard to the
+	// order of its elements, as if each field had the set tag option.
+	// Elements are still counted: []int{1, 1, 2} and []int{1, 2} differ.
+	Sets bool
+}
+
+// Hash returns the 128 bit hash of v's encoding. opts may be nil.
+func Hash(v interface{}, opts *Options) (h1, h2 uint64, err error) {
+	if opts == nil {
+		opts = new(Options)
+	}
+	h := murmur3.SeedNew128(opts.Seed1, opts.Seed2)
+	if err := Encode(h, v, opts); err != nil {
+		return 0, 0, err
+	}
+	h1, h2 = h.Sum128()
+	return h1, h2, nil
+}
+
+// Hash64 returns the first half of Hash.
+func Hash64(v interface{}, opts *Options) (uint64, error) {
+	h1, _, err := Hash(v, opts)
+	return h1, err
+}
+
+// Encode writes the canonical encoding of v that Hash hashes to w. opts may
+// be nil. An error is returned if v cannot be encoded or w fails.
+func Encode(w io.Writer, v interface{}, opts *Options) error {
+	if opts == nil {
+		opts = new(Options)
+	}
+	e := &encoder{
+		w:       w,
+		sets:    opts.Sets,
+		visited: make(map[visit]bool),
+	}
+	return e.encode(reflect.ValueOf(v), opts.Sets)
+}
+
+// visit identifies a pointer, map or slice on the path being encoded, to
+// detect values referring back to themselves.
+type visit struct {
+	ptr uintptr
+	typ reflect.Type
+}
+
+type encoder struct {
+	w       io.Writer
+	sets    bool
+	visited map[visit]bool
+	scratch [17]byte
+}
+
+func (e *encoder) write(p []byte) error {
+	_, err := e.w.Write(p)
+	return err
+}
+
+func (e *encoder) tag(t byte) error {
+	e.scratch[0] = t
+	return e.write(e.scratch[:1])
+}
+
+// tagUint64 writes the tag followed by the 8 little endian bytes of u.
+func (e *encoder) tagUint64(t byte, u uint64) error {
+	e.scratch[0] = t
+	putUint64(e.scratch[1:], u)
+	return e.write(e.scratch[:9])
+}
+
+func (e *encoder) tagUint32(t byte, u uint32) error {
+	e.scratch[0] = t
+	putUint32(e.scratch[1:], u)
+	return e.write(e.scratch[:5])
+}
+
+func (e *encoder) tagUint128(t byte, lo, hi uint64) error {
+	e.scratch[0] = t
+	putUint64(e.scratch[1:], lo)
+	putUint64(e.scratch[9:], hi)
+	return e.write(e.scratch[:17])
+}
+
+func (e *encoder) string(s string) error {
+	if err := e.tagUint64(tagString, uint64(len(s))); err != nil {
+		return err
+	}
+	_, err := io.WriteString(e.w, s)
+	return err
+}
+
+// enter marks a reference as being on the current path, returning errCycle
+// if it already is. leave unmarks it.
+func (e *encoder) enter(v reflect.Value) error {
+	k := visit{v.Pointer(), v.Type()}
+	if e.visited[k] {
+		return errCycle
+	}
+	e.visited[k] = true
+	return nil
+}
+
+func (e *encoder) leave(v reflect.Value) {
+	delete(e.visited, visit{v.Pointer(), v.Type()})
+}
+
+// encode writes v, hashing it as a set if set is true and v is a slice or
+// array.
+func (e *encoder) encode(v reflect.Value, set bool) error {
+	if !v.IsValid() {
+		return e.tag(tagNil)
+	}
+	switch v.Kind() {
+	case reflect.Bool:
+		e.scratch[0], e.scratch[1] = tagBool, 0
+		if v.Bool() {
+			e.scratch[1] = 1
+		}
+		return e.write(e.scratch[:2])
+
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return e.tagUint64(tagInt, uint64(v.Int()))
+
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return e.tagUint64(tagUint, v.Uint())
+
+	case reflect.Float32:
+		return e.tagUint32(tagFloat32, float32bits(float32(v.Float())))
+
+	case reflect.Float64:
+		return e.tagUint64(tagFloat64, float64bits(v.Float()))
+
+	case reflect.Complex64:
+		c := v.Complex()
+		e.scratch[0] = tagComplex64
+		putUint32(e.scratch[1:], float32bits(float32(real(c))))
+		putUint32(e.scratch[5:], float32bits(float32(imag(c))))
+		return e.write(e.scratch[:9])
+
+	case reflect.Complex128:
+		c := v.Complex()
+		return e.tagUint128(tagComplex128, float64bits(real(c)), float64bits(imag(c)))
+
+	case reflect.String:
+		return e.string(v.String())
+
+	case reflect.Ptr, reflect.Interface:
+		if v.IsNil() {
+			return e.tag(tagNil)
+		}
+		if v.Kind() == reflect.Interface {
+			return e.encode(v.Elem(), set)
+		}
+		if err := e.enter(v); err != nil {
+			return err
+		}
+		defer e.leave(v)
+		return e.encode(v.Elem(), set)
+
+	case reflect.Slice:
+		if v.IsNil() {
+			return e.tag(tagNil)
+		}
+		if v.Len() > 0 {
+			if err := e.enter(v); err != nil {
+				return err
+			}
+			defer e.leave(v)
+		}
+		if v.Type().Elem().Kind() == reflect.Uint8 && !set {
+			if err := e.tagUint64(tagBytes, uint64(v.Len())); err != nil {
+				return err
+			}
+			return e.write(v.Bytes())
+		}
+		return e.list(v, set)
+
+	case reflect.Array:
+		return e.list(v, set)
+
+	case reflect.Map:
+		if v.IsNil() {
+			return e.tag(tagNil)
+		}
+		if err := e.enter(v); err != nil {
+			return err
+		}
+		defer e.leave(v)
+		return e.mapping(v)
+
+	case reflect.Struct:
+		if v.Type() == timeType {
+			t := v.Interface().(time.Time)
+			return e.tagUint128(tagTime, uint64(t.Unix()), uint64(t.Nanosecond()))
+		}
+		return e.structure(v)
+	}
+	return fmt.Errorf("structhash: cannot hash %s", v.Type())
+}
+
+// list writes the elements of a slice or array, in order or as a multiset.
+func (e *encoder) list(v reflect.Value, set bool) error {
+	n := v.Len()
+	if !set {
+		if err := e.tagUint64(tagArray, uint64(n)); err != nil {
+			return err
+		}
+		for i := 0; i < n; i++ {
+			if err := e.encode(v.Index(i), e.sets); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	var (
+		ms  murmur3.MultisetHash128
+		buf bytes.Buffer
+		sub = e.sub(&buf)
+	)
+	for i := 0; i < n; i++ {
+		buf.Reset()
+		if err := sub.encode(v.Index(i), e.sets); err != nil {
+			return err
+		}
+		ms.Add(buf.Bytes())
+	}
+	if err := e.tagUint64(tagSet, uint64(n)); err != nil {
+		return err
+	}
+	h1, h2 := ms.Sum128()
+	putUint64(e.scratch[:], h1)
+	putUint64(e.scratch[8:], h2)
+	return e.write(e.scratch[:16])
+}
+
+// mapping writes the entries of a map in the order of their encoded keys.
+func (e *encoder) mapping(v reflect.Value) error {
+	type entry struct {
+		key []byte
+		val reflect.Value
+	}
+	var (
+		buf     bytes.Buffer
+		sub     = e.sub(&buf)
+		entries = make([]entry, 0, v.Len())
+	)
+	for _, k := range v.MapKeys() {
+		buf.Reset()
+		if err := sub.encode(k, e.sets); err != nil {
+			return err
+		}
+		entries = append(entries, entry{
+			key: append([]byte(nil), buf.Bytes()...),
+			val: v.MapIndex(k),
+		})
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return bytes.Compare(entries[i].key, entries[j].key) < 0
+	})
+
+	if err := e.tagUint64(tagMap, uint64(len(entries))); err != nil {
+		return err
+	}
+	for _, ent := range entries {
+		if err := e.write(ent.key); err != nil {
+			return err
+		}
+		if err := e.encode(ent.val, e.sets); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// structure writes the exported, untagged fields of a struct.
+func (e *encoder) structure(v reflect.Value) error {
+	t := v.Type()
+	fields := make([]int, 0, t.NumField())
+	sets := make([]bool, 0, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if f.PkgPath != "" { // unexported
+			continue
+		}
+		name, opts := f.Tag.Get(TagName), ""
+		if comma := strings.IndexByte(name, ','); comma >= 0 {
+			name, opts = name[:comma], name[comma+1:]
+		}
+		if name == "-" {
+			continue
+		}
+		set := e.sets
+		for _, opt := range strings.Split(opts, ",") {
+			if opt == "set" {
+				set = true
+			}
+		}
+		fields = append(fields, i)
+		sets = append(sets, set)
+	}
+
+	if err := e.tagUint64(tagStruct, uint64(len(fields))); err != nil {
+		return err
+	}
+	for j, i := range fields {
+		if err := e.string(t.Field(i).Name); err != nil {
+			return err
+		}
+		if err := e.encode(v.Field(i), sets[j]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// sub returns an encoder writing to w that shares e's options and path.
+func (e *encoder) sub(w io.Writer) *encoder {
+	return &encoder{w: w, sets: e.sets, visited: e.visited}
+}
+
+func float32bits(f float32) uint32 {
+	switch {
+	case f == 0:
+		return 0
+	case f != f:
+		return 0x7fc00000
+	}
+	return math.Float32bits(f)
+}
+
+func float64bits(f float64) uint64 {
+	switch {
+	case f == 0:
+		return 0
+	case f != f:
+		return 0x7ff8000000000000
+	}
+	return math.Float64bits(f)
+}
+
+func putUint32(b []byte, u uint32) {
+	_ = b[3]
+	b[0], b[1], b[2], b[3] = byte(u), byte(u>>8), byte(u>>16), byte(u>>24)
+}
+
+func putUint64(b []byte, u uint64) {
+	_ = b[7]
+	b[0], b[1], b[2], b[3] = byte(u), byte(u>>8), byte(u>>16), byte(u>>24)
+	b[4], b[5], b[6], b[7] = byte(u>>32), byte(u>>40), byte(u>>48), byte(u>>56)
+}
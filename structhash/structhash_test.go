@@ -0,0 +1,206 @@
+package structhash
+
+import (
+	"bytes"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/twmb/murmur3"
+)
+
+func mustHash(t *testing.T, v interface{}, opts *Options) [2]uint64 {
+	t.Helper()
+	h1, h2, err := Hash(v, opts)
+	if err != nil {
+		t.Fatalf("Hash(%#v): %v", v, err)
+	}
+	return [2]uint64{h1, h2}
+}
+
+func TestEncoding(t *testing.T) {
+	for _, test := range []struct {
+		v    interface{}
+		want []byte
+	}{
+		{nil, []byte{tagNil}},
+		{true, []byte{tagBool, 1}},
+		{int8(-1), []byte{tagInt, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff}},
+		{uint16(0x102), []byte{tagUint, 2, 1, 0, 0, 0, 0, 0, 0}},
+		{float32(math.Copysign(0, -1)), []byte{tagFloat32, 0, 0, 0, 0}},
+		{"ab", []byte{tagString, 2, 0, 0, 0, 0, 0, 0, 0, 'a', 'b'}},
+		{[]byte("ab"), []byte{tagBytes, 2, 0, 0, 0, 0, 0, 0, 0, 'a', 'b'}},
+		{[]bool{false}, []byte{tagArray, 1, 0, 0, 0, 0, 0, 0, 0, tagBool, 0}},
+		{(*int)(nil), []byte{tagNil}},
+		{map[string]bool(nil), []byte{tagNil}},
+		{
+			struct {
+				A    bool
+				B    bool `murmur3:"-"`
+				priv bool
+			}{},
+			[]byte{
+				tagStruct, 1, 0, 0, 0, 0, 0, 0, 0,
+				tagString, 1, 0, 0, 0, 0, 0, 0, 0, 'A',
+				tagBool, 0,
+			},
+		},
+	} {
+		var buf bytes.Buffer
+		if err := Encode(&buf, test.v, nil); err != nil {
+			t.Errorf("Encode(%#v): %v", test.v, err)
+			continue
+		}
+		if !bytes.Equal(buf.Bytes(), test.want) {
+			t.Errorf("Encode(%#v) = %v, want %v", test.v, buf.Bytes(), test.want)
+		}
+		h1, h2 := murmur3.Sum128(test.want)
+		if got := mustHash(t, test.v, nil); got != [2]uint64{h1, h2} {
+			t.Errorf("Hash(%#v) is not Sum128 of its encoding", test.v)
+		}
+	}
+}
+
+func TestDistinct(t *testing.T) {
+	values := []interface{}{
+		nil, false, true, 0, 1, uint(1), "1", "", []byte{}, []byte("1"),
+		[]int{}, []int{1}, float32(1), 1.0, complex(1, 0),
+		map[int]int{}, map[int]int{1: 1}, struct{}{}, struct{ A int }{1},
+		struct{ B int }{1}, time.Unix(1, 0),
+		[]string{"a", "b"}, []string{"ab"}, []string{"b", "a"},
+	}
+	seen := make(map[[2]uint64]int)
+	for i, v := range values {
+		h := mustHash(t, v, nil)
+		if j, ok := seen[h]; ok {
+			t.Errorf("%#v and %#v hash equal", values[j], v)
+		}
+		seen[h] = i
+	}
+
+	// Only the kind family is tagged, not the exact type.
+	one := 1
+	for _, v := range []interface{}{int8(1), int64(1), &one, interface{}(1)} {
+		if mustHash(t, v, nil) != mustHash(t, 1, nil) {
+			t.Errorf("%#v does not hash as 1", v)
+		}
+	}
+	if mustHash(t, [1]int{1}, nil) != mustHash(t, []int{1}, nil) {
+		t.Error("array and slice hash differently")
+	}
+}
+
+func TestCanonical(t *testing.T) {
+	type inner struct {
+		Tags []string `murmur3:",set"`
+		When time.Time
+	}
+	type outer struct {
+		Name  string
+		Attrs map[string]int
+		In    *inner
+		Any   interface{}
+	}
+	when := time.Date(2020, 1, 2, 3, 4, 5, 6, time.UTC)
+	a := outer{
+		Name:  "x",
+		Attrs: map[string]int{"a": 1, "b": 2, "c": 3},
+		In:    &inner{Tags: []string{"p", "q", "q"}, When: when},
+		Any:   []float64{math.NaN()},
+	}
+	b := outer{
+		Name:  "x",
+		Attrs: map[string]int{"c": 3, "a": 1, "b": 2},
+		In:    &inner{Tags: []string{"q", "p", "q"}, When: when.In(time.FixedZone("x", 3600))},
+		Any:   []float64{-math.NaN()},
+	}
+	if mustHash(t, a, nil) != mustHash(t, b, nil) {
+		t.Error("equal values hash differently")
+	}
+
+	b.In.Tags = []string{"p", "q"}
+	if mustHash(t, a, nil) == mustHash(t, b, nil) {
+		t.Error("set tag ignored element counts")
+	}
+	b.In.Tags = []string{"q", "p", "q"}
+	b.Attrs["a"] = 4
+	if mustHash(t, a, nil) == mustHash(t, b, nil) {
+		t.Error("map value change did not change the hash")
+	}
+}
+
+func TestOptions(t *testing.T) {
+	x, y := []int{1, 2, 3}, []int{3, 1, 2}
+	if mustHash(t, x, nil) == mustHash(t, y, nil) {
+		t.Error("ordered slices hash equal")
+	}
+	sets := &Options{Sets: true}
+	if mustHash(t, x, sets) != mustHash(t, y, sets) {
+		t.Error("Sets did not make slices order insensitive")
+	}
+	nested := [][]int{{1, 2}, {3, 4}}
+	swapped := [][]int{{4, 3}, {2, 1}}
+	if mustHash(t, nested, sets) != mustHash(t, swapped, sets) {
+		t.Error("Sets did not apply to nested slices")
+	}
+
+	seeded := &Options{Seed1: 1, Seed2: 2}
+	var buf bytes.Buffer
+	if err := Encode(&buf, x, nil); err != nil {
+		t.Fatal(err)
+	}
+	h1, h2 := murmur3.SeedSum128(1, 2, buf.Bytes())
+	if mustHash(t, x, seeded) != [2]uint64{h1, h2} {
+		t.Error("seeds not applied")
+	}
+	if h, err := Hash64(x, seeded); err != nil || h != h1 {
+		t.Errorf("Hash64 = %x, %v; want %x", h, err, h1)
+	}
+}
+
+func TestErrors(t *testing.T) {
+	type node struct {
+		Next *node
+	}
+	loop := &node{}
+	loop.Next = loop
+	self := make(map[string]interface{})
+	self["self"] = self
+
+	for _, v := range []interface{}{
+		func() {},
+		make(chan int),
+		struct{ F func() }{},
+		loop,
+		self,
+	} {
+		if _, _, err := Hash(v, nil); err == nil {
+			t.Errorf("Hash(%T) succeeded", v)
+		}
+	}
+
+	// Shared, acyclic references are fine.
+	shared := &node{}
+	if _, _, err := Hash([]*node{shared, shared}, nil); err != nil {
+		t.Errorf("shared pointers: %v", err)
+	}
+}
+
+func BenchmarkHash(b *testing.B) {
+	type item struct {
+		ID    int64
+		Name  string
+		Tags  []string
+		Attrs map[string]string
+	}
+	v := item{
+		ID:    12345,
+		Name:  "benchmark",
+		Tags:  []string{"a", "b", "c"},
+		Attrs: map[string]string{"k1": "v1", "k2": "v2"},
+	}
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		Hash(v, nil)
+	}
+}
@@ -4,6 +4,9 @@ package testdata
 // #include <stdint.h>
 // #include "MurmurHash3.cpp"
 // #include "MurmurHash3.h"
+//
+// static uint32_t ref_fmix32(uint32_t h) { return fmix32(h); }
+// static uint64_t ref_fmix64(uint64_t k) { return fmix64(k); }
 import "C"
 
 import "unsafe"
@@ -43,3 +46,24 @@ func SeedSum128(seed uint32, data []byte) (h1, h2 uint64) {
 	C.MurmurHash3_x64_128(p, C.int(len(data)), C.uint32_t(seed), unsafe.Pointer(&out))
 	return out.h1, out.h2
 }
+
+func SeedSum128x86(seed uint32, data []byte) (h1, h2 uint64) {
+	var p unsafe.Pointer
+	if len(data) > 0 {
+		p = unsafe.Pointer(&data[0])
+	}
+	var out struct {
+		h1 uint64
+		h2 uint64
+	}
+	C.MurmurHash3_x86_128(p, C.int(len(data)), C.uint32_t(seed), unsafe.Pointer(&out))
+	return out.h1, out.h2
+}
+
+func Fmix32(h uint32) uint32 {
+	return uint32(C.ref_fmix32(C.uint32_t(h)))
+}
+
+func Fmix64(k uint64) uint64 {
+	return uint64(C.ref_fmix64(C.uint64_t(k)))
+}